@@ -1,37 +1,54 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/eupneart/auth-service/internal/api"
+	"github.com/eupneart/auth-service/internal/authserver"
+	"github.com/eupneart/auth-service/internal/connectors"
+	"github.com/eupneart/auth-service/internal/crypto"
 	"github.com/eupneart/auth-service/internal/db"
+	"github.com/eupneart/auth-service/internal/keys"
+	"github.com/eupneart/auth-service/internal/mail"
+	"github.com/eupneart/auth-service/internal/oidcauth"
 	"github.com/eupneart/auth-service/internal/repositories"
 	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/internal/services/revocationdigest"
+	"github.com/eupneart/auth-service/internal/services/sessions"
+	"github.com/eupneart/auth-service/internal/storage"
+	transportgrpc "github.com/eupneart/auth-service/internal/transport/grpc"
+	"github.com/eupneart/auth-service/pkg/config"
 	"github.com/eupneart/auth-service/pkg/env"
+	"github.com/eupneart/auth-service/utils"
 	_ "github.com/jackc/pgconn"
 	_ "github.com/jackc/pgx/v4"
 	_ "github.com/jackc/pgx/v4/stdlib"
+	"google.golang.org/grpc"
+
+	authv1 "github.com/eupneart/auth-service/gen/auth/v1"
 )
 
 func main() {
-	// Initialize configuration using your env utility
-	cfg := env.LoadEnv()
-
-	// Initialize structured logger with appropriate level
-	logLevel := slog.LevelInfo
-	if env.IsDevelopment() {
-		logLevel = slog.LevelDebug
+	// Load configuration by layering defaults, an optional config file
+	// (AUTH_CONFIG), and the environment. This also installs the root
+	// structured logger (env.Logger), which stays a process-wide global;
+	// cfg itself is threaded explicitly into everything below rather than
+	// published to env.Config.
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("loading configuration: %v", err)
 	}
-
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
-	slog.SetDefault(logger)
+	logger := env.Logger
 
 	logger.Info("Starting authentication service",
 		slog.String("app_env", cfg.AppEnv),
@@ -40,7 +57,7 @@ func main() {
 	// Validate JWT secret
 	if cfg.JWTSecret == "defaultsecret" {
 		logger.Warn("Using default JWT secret - this MUST be changed in production!")
-		if env.IsProduction() {
+		if cfg.IsProduction() {
 			log.Panic("Default JWT secret is not allowed in production!")
 		}
 	}
@@ -54,26 +71,319 @@ func main() {
 	logger.Info("Successfully connected to database")
 
 	// Initialize repositories
-	userRepo := repositories.NewUserRepo(conn)
-	tokenRepo := repositories.NewTokenRepo(conn)
+	auditRepo := repositories.NewAuditRepo(conn)
+	// AuditingUserRepo wraps the plain UserRepo so every Insert/Update/
+	// DeleteByID also writes a user_audit_log row, in the same transaction,
+	// recording whichever actor WithActor found on ctx (see UserService).
+	userRepo := repositories.NewAuditingUserRepo(repositories.NewUserRepo(conn), conn, auditRepo)
+	sessionRepo := repositories.NewSessionRepo(conn)
+	passwordResetRepo := repositories.NewPasswordResetRepo(conn)
+	identityRepo := repositories.NewIdentityRepo(conn)
+	loginAttemptRepo := repositories.NewLoginAttemptRepo(conn)
+	registrationTokenRepo := repositories.NewRegistrationTokenRepo(conn)
+	deviceAuthRepo := repositories.NewDeviceAuthRepo(conn)
+
+	// MFA is opt-in via MFA_ENABLED, since it depends on the mfa_credentials/
+	// mfa_recovery_codes tables existing: deployments that haven't migrated
+	// them yet would otherwise fail every login the moment GenerateTokens
+	// tried to check enrollment.
+	var mfaService services.MFAService
+	if env.GetEnvAsBool("MFA_ENABLED", false) {
+		mfaRepo := repositories.NewMFARepo(conn)
+
+		// MFA_ENCRYPTION_KEY is optional: when unset, TOTP secrets are
+		// stored in mfa_credentials as plaintext, same as before this
+		// encryptor existed. When set it must decode to a 16/24/32-byte
+		// AES key, the same sizing NewAESGCMEncryptor enforces.
+		var mfaEncryptor crypto.Encryptor
+		if rawKey := env.GetEnv("MFA_ENCRYPTION_KEY", ""); rawKey != "" {
+			key, err := base64.StdEncoding.DecodeString(rawKey)
+			if err != nil {
+				logger.Error("failed to decode MFA_ENCRYPTION_KEY as base64", slog.String("error", err.Error()))
+				log.Panic(err)
+			}
+			mfaEncryptor, err = crypto.NewAESGCMEncryptor(key)
+			if err != nil {
+				logger.Error("failed to initialize mfa encryptor", slog.String("error", err.Error()))
+				log.Panic(err)
+			}
+		}
+
+		mfaService = services.NewMFAService(mfaRepo, cfg.JWTIssuer, mfaEncryptor, logger)
+	}
+
+	// TokenStore backend is pluggable via TOKEN_STORE: "postgres" (default),
+	// "redis" for deployments where the per-request revocation check can't
+	// afford a Postgres round-trip, "bolt" for a single-node deployment
+	// that still wants metadata to survive a restart, or "memory" for
+	// local development.
+	tokenRepo, err := storage.Open(storage.Config{
+		Driver:        cfg.TokenStoreBackend,
+		Postgres:      conn,
+		RedisAddr:     cfg.RedisAddr,
+		RedisPassword: cfg.RedisPassword,
+		RedisDB:       cfg.RedisDB,
+		BoltPath:      cfg.BoltPath,
+	})
+	if err != nil {
+		logger.Error("Failed to open token store", slog.String("error", err.Error()))
+		log.Panic(err)
+	}
+	logger.Info("Token store opened", slog.String("driver", cfg.TokenStoreBackend))
+
+	// Initialize the signing key manager, persisting keys under JWT_KEY_DIR
+	// so a restart doesn't invalidate already-issued tokens. JWT_SIGNING_ALG
+	// selects RS256 (default) or ES256; keys already on disk from a prior
+	// setting still load and verify regardless of this value.
+	signingAlg := keys.Algorithm(env.GetEnv("JWT_SIGNING_ALG", string(keys.AlgRS256)))
+	keyManager := keys.NewManager(env.GetEnv("JWT_KEY_DIR", "./keys"), 24*time.Hour, signingAlg)
+	if err := keyManager.Init(); err != nil {
+		logger.Error("Failed to initialize signing key manager", slog.String("error", err.Error()))
+		log.Panic(err)
+	}
+
+	// Password rules are configurable via PASSWORD_* so an operator can
+	// relax or tighten them without a code change; unset variables keep
+	// utils.DefaultPasswordPolicy's behavior.
+	utils.ActivePasswordPolicy = utils.PasswordPolicy{
+		MinLength:      env.GetEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:      env.GetEnvAsInt("PASSWORD_MAX_LENGTH", 128),
+		RequireUpper:   env.GetEnvAsBool("PASSWORD_REQUIRE_UPPER", true),
+		RequireLower:   env.GetEnvAsBool("PASSWORD_REQUIRE_LOWER", true),
+		RequireNumber:  env.GetEnvAsBool("PASSWORD_REQUIRE_NUMBER", true),
+		RequireSpecial: env.GetEnvAsBool("PASSWORD_REQUIRE_SPECIAL", true),
+	}
 
 	// Create TokenService configuration using .env.* cfg
 	tokenConfig := services.TokenServiceConfig{
-		JWTSecret: cfg.JWTSecret,
-		Issuer:    cfg.JWTIssuer,
+		Issuer: cfg.JWTIssuer,
 		// Get token durations from environment with sensible defaults
 		AccessTokenDuration:  env.GetEnvAsDuration("JWT_ACCESS_TOKEN_DURATION", "15m"),
 		RefreshTokenDuration: env.GetEnvAsDuration("JWT_REFRESH_TOKEN_DURATION", "168h"), // 7 days
 	}
 
+	// The live session cache sits in front of SessionRepo so checking or
+	// touching a session doesn't cost a DB round-trip per request; its
+	// background loop reaps expired sessions and flushes last-seen activity.
+	sessionStore := sessions.NewStore(sessionRepo, logger)
+	sessionStore.Start()
+	if notifyingTokenRepo, ok := tokenRepo.(*repositories.TokenRepo); ok {
+		notifyingTokenRepo.SessionNotifier = sessionStore
+	}
+
+	// Audit revocations the same way AuditingUserRepo audits user
+	// mutations, when the TokenStore backend is Postgres (the redis and
+	// in-memory backends have no audit table to write to). tokenRepo
+	// itself is left unwrapped so the TokenAdminRepository/
+	// RevocationRepository assertions below still see the concrete
+	// *repositories.TokenRepo.
+	tokenStore := tokenRepo
+	if pgTokenRepo, ok := tokenRepo.(*repositories.TokenRepo); ok {
+		tokenStore = repositories.NewAuditingTokenStore(pgTokenRepo, conn, auditRepo, logger)
+	}
+
 	// Create services
-	userService := services.New(userRepo)
-	tokenService := services.NewTokenService(tokenConfig, userRepo, tokenRepo, logger)
+	var passwordHasher services.PasswordHasher
+	if cfg.PasswordHashAlgo == "argon2id" {
+		passwordHasher = services.NewArgon2idHasher(services.DefaultArgon2idParams())
+	} else {
+		passwordHasher = services.NewBcryptHasher(cfg.BcryptCost)
+	}
+
+	userService := services.New(userRepo, passwordHasher)
+	tokenService := services.NewTokenService(tokenConfig, userRepo, tokenStore, keyManager, mfaService, logger)
+	sessionService := services.NewSessionService(sessionRepo, tokenStore, sessionStore, tokenConfig.RefreshTokenDuration, logger)
+	userService.SessionService = sessionService
+	userService.IdentityRepo = identityRepo
+
+	// Use a real SMTP relay when configured, otherwise log reset emails
+	// instead of sending them so local development doesn't need one.
+	var mailer mail.Mailer
+	if cfg.SMTPHost != "" {
+		mailer = mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+	} else {
+		mailer = mail.NewNoopMailer()
+	}
+	passwordResetService := services.NewPasswordResetService(passwordResetRepo, userService, tokenStore, mailer, cfg.AppBaseURL, logger)
+	loginProtectionService := services.NewLoginProtectionService(loginAttemptRepo, userService, mailer, logger)
+
+	// Registration is open (no invite required) unless REQUIRE_REGISTRATION_TOKEN
+	// is set, so existing deployments aren't suddenly locked out of signup.
+	var registrationTokenService services.RegistrationTokenService
+	if env.GetEnvAsBool("REQUIRE_REGISTRATION_TOKEN", false) {
+		registrationTokenService = services.NewRegistrationTokenService(registrationTokenRepo, logger)
+	}
+
+	// The device flow (RFC 8628) lets a device without a browser, e.g. a
+	// CLI or TV app, sign a user in via a code they approve on a second
+	// device. It's purely additive, so it's always on.
+	deviceAuthService := services.NewDeviceAuthService(deviceAuthRepo, userService, tokenService, logger)
+
+	// The embedded OIDC authorization server is opt-in via
+	// OIDC_AUTHSERVER_ENABLED, since it depends on the oauth_clients/
+	// oauth_authorization_requests tables existing - deployments that
+	// haven't migrated them yet leave it nil and /authorize, /token (the
+	// authserver one), /userinfo, and /end_session simply aren't mounted.
+	var authServer *authserver.Service
+	if env.GetEnvAsBool("OIDC_AUTHSERVER_ENABLED", false) {
+		oauthClientRepo := repositories.NewOAuthClientRepo(conn)
+		authRequestRepo := repositories.NewAuthRequestRepo(conn)
+		authServer = authserver.NewService(oauthClientRepo, authRequestRepo, userService, tokenService, keyManager, cfg.JWTIssuer, logger)
+	}
+
+	// The admin token dashboard/export needs keyset-paginated filtering
+	// over token_metadata, which only the Postgres TokenStore backend
+	// supports; it's nil (and /admin/tokens isn't mounted) under redis/memory.
+	var tokenAdminService services.TokenAdminService
+	if tokenAdminRepo, ok := tokenRepo.(repositories.TokenAdminRepository); ok {
+		tokenAdminService = services.NewTokenAdminService(tokenAdminRepo, logger)
+	}
+
+	// The revocation digest lets resource servers check revocation
+	// locally via a signed Bloom filter instead of a TokenStore
+	// round-trip per request; same Postgres-only scoping as tokenAdminService.
+	var revocationDigestService *revocationdigest.Service
+	if revocationRepo, ok := tokenRepo.(repositories.RevocationRepository); ok {
+		revocationDigestService = revocationdigest.NewService(
+			revocationRepo,
+			keyManager,
+			env.GetEnvAsInt("REVOCATION_DIGEST_CAPACITY", revocationdigest.DefaultCapacity),
+			revocationdigest.DefaultFalsePositiveRate,
+			env.GetEnvAsDuration("REVOCATION_DIGEST_INTERVAL", "30s"),
+			logger,
+		)
+		revocationDigestService.Start()
+	}
+
+	// Register the external identity connectors enabled via CONNECTORS_ENABLED
+	connectorRegistry := connectors.NewRegistry()
+	for _, name := range cfg.ConnectorsEnabled {
+		switch name {
+		case "oidc":
+			connectorRegistry.Register(connectors.NewOIDCConnector(connectors.OIDCConfig{
+				ClientID:     env.GetEnv("CONNECTOR_OIDC_CLIENT_ID", ""),
+				ClientSecret: env.GetEnv("CONNECTOR_OIDC_CLIENT_SECRET", ""),
+				IssuerURL:    env.GetEnv("CONNECTOR_OIDC_ISSUER_URL", ""),
+				AuthURL:      env.GetEnv("CONNECTOR_OIDC_AUTH_URL", ""),
+				TokenURL:     env.GetEnv("CONNECTOR_OIDC_TOKEN_URL", ""),
+				UserInfoURL:  env.GetEnv("CONNECTOR_OIDC_USERINFO_URL", ""),
+				RedirectURL:  env.GetEnv("CONNECTOR_OIDC_REDIRECT_URL", ""),
+				Scopes:       []string{"openid", "email", "profile"},
+			}, userService))
+		case "ldap":
+			connectorRegistry.Register(connectors.NewLDAPConnector(connectors.LDAPConfig{
+				Host:       env.GetEnv("CONNECTOR_LDAP_HOST", "localhost"),
+				Port:       env.GetEnvAsInt("CONNECTOR_LDAP_PORT", 389),
+				BaseDN:     env.GetEnv("CONNECTOR_LDAP_BASE_DN", ""),
+				UserFilter: env.GetEnv("CONNECTOR_LDAP_USER_FILTER", "(uid=%s)"),
+				BindDN:     env.GetEnv("CONNECTOR_LDAP_BIND_DN", ""),
+				BindPass:   env.GetEnv("CONNECTOR_LDAP_BIND_PASSWORD", ""),
+			}, userService))
+		case "github":
+			connectorRegistry.Register(connectors.NewGitHubConnector(connectors.GitHubConfig{
+				ClientID:     env.GetEnv("CONNECTOR_GITHUB_CLIENT_ID", ""),
+				ClientSecret: env.GetEnv("CONNECTOR_GITHUB_CLIENT_SECRET", ""),
+				RedirectURL:  env.GetEnv("CONNECTOR_GITHUB_REDIRECT_URL", ""),
+			}, userService))
+		default:
+			logger.Warn("Unknown connector requested via CONNECTORS_ENABLED", slog.String("connector", name))
+		}
+	}
+
+	// Discover the OIDC social login providers enabled via OIDC_PROVIDERS.
+	// Discovery failures are non-fatal: a misconfigured provider is logged
+	// and social login is simply unavailable rather than blocking startup.
+	var oidcLoginManager *oidcauth.Manager
+	if len(cfg.OIDCProviders) > 0 {
+		manager, err := oidcauth.NewManager(context.Background(), cfg.OIDCProviders)
+		if err != nil {
+			logger.Error("Failed to initialize OIDC social login providers", slog.String("error", err.Error()))
+		} else {
+			oidcLoginManager = manager
+		}
+	}
 
 	logger.Info("Services initialized successfully")
 
 	// Create the API server
-	server := api.NewServer(cfg, userService, tokenService)
+	server := api.NewServer(cfg, userService, tokenService, keyManager, connectorRegistry, sessionService, passwordResetService, oidcLoginManager, loginProtectionService, registrationTokenService, deviceAuthService, tokenAdminService, revocationDigestService, mfaService, authServer)
+
+	// Start the internal gRPC transport alongside the HTTP API so internal
+	// callers can reach AuthService without HTTP overhead.
+	grpcPort := env.GetEnv("GRPC_PORT", "50051")
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", grpcPort))
+	if err != nil {
+		logger.Error("Failed to open gRPC listener", slog.String("error", err.Error()))
+		log.Panic(err)
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			transportgrpc.TimeoutInterceptor(),
+			transportgrpc.LoggingInterceptor(logger),
+			transportgrpc.AuthInterceptor(tokenService),
+		),
+	)
+	authv1.RegisterAuthServiceServer(grpcServer, transportgrpc.NewServer(userService, tokenService))
+
+	go func() {
+		logger.Info("gRPC server starting", slog.String("address", grpcListener.Addr().String()))
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Error("gRPC server failed", slog.String("error", err.Error()))
+		}
+	}()
+
+	// Periodically sweep expired token metadata so the store doesn't grow
+	// unbounded with rows nobody will ever query again.
+	go func() {
+		interval := env.GetEnvAsDuration("TOKEN_CLEANUP_INTERVAL", "1h")
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := tokenService.CleanupExpiredTokens(context.Background()); err != nil {
+				logger.Error("Expired token cleanup failed", slog.String("error", err.Error()))
+			}
+		}
+	}()
+
+	// Rotate the JWT signing key on a schedule and on SIGHUP, so a key can
+	// be retired without restarting the process; verification keeps
+	// working for tokens signed by the retired key during its overlap
+	// window via keyManager.Lookup.
+	go func() {
+		interval := env.GetEnvAsDuration("JWT_KEY_ROTATION_INTERVAL", "24h")
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+
+		rotate := func(trigger string) {
+			if _, err := keyManager.Rotate(); err != nil {
+				logger.Error("JWT signing key rotation failed",
+					slog.String("trigger", trigger),
+					slog.String("error", err.Error()))
+				return
+			}
+			logger.Info("Rotated JWT signing key", slog.String("trigger", trigger))
+		}
+
+		for {
+			select {
+			case <-ticker.C:
+				rotate("interval")
+			case <-sighup:
+				rotate("sighup")
+			}
+		}
+	}()
 
 	// Log configuration (be careful not to log sensitive data)
 	logger.Info("Server configuration",
@@ -84,7 +394,7 @@ func main() {
 		slog.String("jwt_issuer", cfg.JWTIssuer),
 		slog.Duration("access_token_duration", tokenConfig.AccessTokenDuration),
 		slog.Duration("refresh_token_duration", tokenConfig.RefreshTokenDuration),
-		slog.Bool("is_production", env.IsProduction()))
+		slog.Bool("is_production", cfg.IsProduction()))
 
 	// Define the http server
 	srv := &http.Server{
@@ -95,13 +405,38 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// On SIGTERM/SIGINT, stop accepting new connections and flush the live
+	// session cache before exiting, so an orchestrator-initiated restart
+	// doesn't lose last-seen activity that hadn't hit its flush tick yet.
+	shutdownSignal := make(chan os.Signal, 1)
+	signal.Notify(shutdownSignal, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-shutdownSignal
+		logger.Info("Shutting down", slog.String("signal", sig.String()))
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := sessionStore.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Failed to flush session store on shutdown", slog.String("error", err.Error()))
+		}
+		if revocationDigestService != nil {
+			if err := revocationDigestService.Shutdown(shutdownCtx); err != nil {
+				logger.Error("Failed to stop revocation digest service on shutdown", slog.String("error", err.Error()))
+			}
+		}
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Failed to gracefully shut down HTTP server", slog.String("error", err.Error()))
+		}
+	}()
+
 	// Start server
 	logger.Info("HTTP server starting",
 		slog.String("address", srv.Addr),
 		slog.String("version", "1.0.0"))
 
-	err := srv.ListenAndServe()
-	if err != nil {
+	err = srv.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		logger.Error("Server failed to start", slog.String("error", err.Error()))
 		log.Panic(err)
 	}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminHandler lets an operator mint and manage the registration tokens
+// that gate the signup flow. Every route here sits behind
+// RequireAuth + RequireRole("admin") (see routes.go); only a caller whose
+// token carries the admin role can reach it.
+type AdminHandler struct {
+	RegistrationTokenService services.RegistrationTokenService
+
+	// TokenAdminService is optional: when set, ListTokens is mounted at
+	// GET /admin/tokens. It's nil whenever the TokenStore backend isn't
+	// Postgres (see main.go).
+	TokenAdminService services.TokenAdminService
+}
+
+func NewAdminHandler(registrationTokenService services.RegistrationTokenService) *AdminHandler {
+	return &AdminHandler{RegistrationTokenService: registrationTokenService}
+}
+
+// IssueRegistrationToken handles POST /admin/registration_tokens.
+func (h *AdminHandler) IssueRegistrationToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var requestPayload struct {
+		UsesAllowed int       `json:"uses_allowed"`
+		ExpiresAt   time.Time `json:"expires_at"`
+		PendingRole string    `json:"pending_role"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for registration token issuance",
+			"error", err,
+			"method", "AdminHandler.IssueRegistrationToken")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.UsesAllowed <= 0 {
+		utils.ErrorJSON(w, errors.New("uses_allowed must be positive"), http.StatusBadRequest)
+		return
+	}
+	if requestPayload.ExpiresAt.IsZero() || !requestPayload.ExpiresAt.After(time.Now()) {
+		utils.ErrorJSON(w, errors.New("expires_at must be in the future"), http.StatusBadRequest)
+		return
+	}
+
+	rawToken, token, err := h.RegistrationTokenService.IssueToken(ctx, requestPayload.UsesAllowed, requestPayload.ExpiresAt, requestPayload.PendingRole, claims.UserID)
+	if err != nil {
+		logger.Error("failed to issue registration token",
+			"error", err,
+			"method", "AdminHandler.IssueRegistrationToken")
+		utils.ErrorJSON(w, errors.New("failed to issue registration token"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data: map[string]interface{}{
+			"token":              rawToken,
+			"registration_token": token,
+		},
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusCreated)
+}
+
+// ListRegistrationTokens handles GET /admin/registration_tokens.
+func (h *AdminHandler) ListRegistrationTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	tokens, err := h.RegistrationTokenService.ListTokens(ctx)
+	if err != nil {
+		logger.Error("failed to list registration tokens",
+			"error", err,
+			"method", "AdminHandler.ListRegistrationTokens")
+		utils.ErrorJSON(w, errors.New("failed to list registration tokens"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data:  tokens,
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// UpdateRegistrationToken handles PUT /admin/registration_tokens/{id},
+// extending or shrinking a still-unused invite's limits.
+func (h *AdminHandler) UpdateRegistrationToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.ErrorJSON(w, errors.New("registration token id is required"), http.StatusBadRequest)
+		return
+	}
+
+	var requestPayload struct {
+		UsesAllowed int       `json:"uses_allowed"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for registration token update",
+			"error", err,
+			"method", "AdminHandler.UpdateRegistrationToken")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.RegistrationTokenService.UpdateToken(ctx, id, requestPayload.UsesAllowed, requestPayload.ExpiresAt); err != nil {
+		logger.Warn("failed to update registration token",
+			"error", err,
+			"token_id", id,
+			"method", "AdminHandler.UpdateRegistrationToken")
+		utils.ErrorJSON(w, errors.New("failed to update registration token"), http.StatusBadRequest)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "registration token updated",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// DeleteRegistrationToken handles DELETE /admin/registration_tokens/{id}.
+func (h *AdminHandler) DeleteRegistrationToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.ErrorJSON(w, errors.New("registration token id is required"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.RegistrationTokenService.RevokeToken(ctx, id); err != nil {
+		logger.Warn("failed to delete registration token",
+			"error", err,
+			"token_id", id,
+			"method", "AdminHandler.DeleteRegistrationToken")
+		utils.ErrorJSON(w, errors.New("failed to delete registration token"), http.StatusBadRequest)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "registration token deleted",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// ListTokens handles GET /admin/tokens?filter=...&cursor=...&limit=...,
+// returning one keyset-paginated page of token metadata. filter uses a
+// field:value grammar, e.g. "user_id:42 AND token_type:refresh"; clauses
+// within a group are ANDed, groups are separated by " OR ". OR groups
+// aren't supported here since unioning independently-paginated result
+// sets isn't implemented - use a single AND group, or see the streaming
+// export for broader queries.
+func (h *AdminHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	groups, err := parseTokenFilterGroups(r.URL.Query().Get("filter"))
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	if len(groups) > 1 {
+		utils.ErrorJSON(w, errors.New("OR filters are not supported for paginated listing"), http.StatusBadRequest)
+		return
+	}
+
+	limit := 50
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			utils.ErrorJSON(w, errors.New("limit must be a positive integer"), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	tokens, nextCursor, err := h.TokenAdminService.ListTokens(ctx, groups[0], r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		logger.Error("failed to list tokens for admin",
+			"error", err,
+			"method", "AdminHandler.ListTokens")
+		utils.ErrorJSON(w, errors.New("failed to list tokens"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data: map[string]interface{}{
+			"tokens":      tokens,
+			"next_cursor": nextCursor,
+		},
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
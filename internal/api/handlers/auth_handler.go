@@ -4,18 +4,37 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
 	"github.com/eupneart/auth-service/internal/models"
 	"github.com/eupneart/auth-service/internal/services"
 	"github.com/eupneart/auth-service/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 type AuthHandler struct {
 	UserService  *services.UserService
 	TokenService services.TokenService
+
+	// SessionService and MaxActiveSessions are optional: when SessionService
+	// is set, Authenticate records a session per device and enforces
+	// MaxActiveSessions by evicting the oldest one. A zero MaxActiveSessions
+	// means unlimited.
+	SessionService    services.SessionService
+	MaxActiveSessions int
+
+	// LoginProtectionService is optional: when set, Authenticate rejects
+	// requests from a locked-out (email, remote IP) pair and records
+	// failures/successes to drive that lockout.
+	LoginProtectionService services.LoginProtectionService
+
+	// RegistrationTokenService is optional: when set, Register accepts a
+	// registration_token field and requires it to redeem successfully
+	// before creating the account, pre-assigning the token's PendingRole.
+	RegistrationTokenService services.RegistrationTokenService
 }
 
 func NewAuthHandler(userService *services.UserService, tokenService services.TokenService) *AuthHandler {
@@ -26,101 +45,144 @@ func NewAuthHandler(userService *services.UserService, tokenService services.Tok
 }
 
 func (h *AuthHandler) Authenticate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	var requestPayload struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		DeviceID string `json:"device_id,omitempty"`
 	}
 
 	err := utils.ReadJSON(w, r, &requestPayload)
 	if err != nil {
-		slog.Error("failed to read JSON payload for authentication",
+		logger.Error("failed to read JSON payload for authentication",
 			"error", err,
-			"method", "AuthHandler.Authenticate",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Authenticate")
 		utils.ErrorJSON(w, err, http.StatusBadRequest)
 		return
 	}
 
 	// Validate input
 	if requestPayload.Email == "" || requestPayload.Password == "" {
-		slog.Warn("authentication attempt with missing credentials",
-			"method", "AuthHandler.Authenticate",
-			"remote_addr", r.RemoteAddr)
+		logger.Warn("authentication attempt with missing credentials",
+			"method", "AuthHandler.Authenticate")
 		utils.ErrorJSON(w, errors.New("email and password are required"), http.StatusBadRequest)
 		return
 	}
 
-	// validate the user against the database
-	user, err := h.UserService.GetByEmail(context.Background(), requestPayload.Email)
+	if h.LoginProtectionService != nil {
+		locked, retryAfter, err := h.LoginProtectionService.CheckLocked(ctx, requestPayload.Email, appmiddleware.ClientIP(r))
+		if err != nil {
+			logger.Error("failed to check account lockout status",
+				"error", err,
+				"email", requestPayload.Email,
+				"method", "AuthHandler.Authenticate")
+		} else if locked {
+			logger.Warn("authentication attempt against locked account",
+				"email", requestPayload.Email,
+				"retry_after", retryAfter,
+				"method", "AuthHandler.Authenticate")
+			w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+			utils.ErrorJSON(w, services.ErrAccountLocked, http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	// validate the user against the database. Authenticate returns the same
+	// ErrInvalidCredentials whether the email is unknown or the password is
+	// wrong, so this response can't be used to enumerate valid accounts.
+	user, err := h.UserService.Authenticate(ctx, requestPayload.Email, requestPayload.Password)
 	if err != nil {
-		slog.Error("failed to get user by email during authentication",
-			"error", err,
+		logger.Warn("authentication failed",
 			"email", requestPayload.Email,
-			"method", "AuthHandler.Authenticate",
-			"remote_addr", r.RemoteAddr)
-		utils.ErrorJSON(w, errors.New("invalid credentials"), http.StatusUnauthorized)
+			"method", "AuthHandler.Authenticate")
+		h.recordLoginFailure(ctx, requestPayload.Email, appmiddleware.ClientIP(r))
+		utils.ErrorJSON(w, services.ErrInvalidCredentials, http.StatusUnauthorized)
 		return
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		slog.Warn("authentication attempt for inactive user",
+		logger.Warn("authentication attempt for inactive user",
 			"email", requestPayload.Email,
 			"user_id", user.ID,
-			"method", "AuthHandler.Authenticate",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Authenticate")
 		utils.ErrorJSON(w, errors.New("account is deactivated"), http.StatusUnauthorized)
 		return
 	}
 
-	valid, err := h.UserService.PasswordMatches(user, requestPayload.Password)
-	if err != nil {
-		slog.Error("error checking password during authentication",
-			"error", err,
-			"email", requestPayload.Email,
-			"method", "AuthHandler.Authenticate",
-			"remote_addr", r.RemoteAddr)
-		utils.ErrorJSON(w, errors.New("invalid credentials"), http.StatusUnauthorized)
-		return
-	}
-
-	if !valid {
-		slog.Warn("invalid password attempt",
-			"email", requestPayload.Email,
-			"method", "AuthHandler.Authenticate",
-			"remote_addr", r.RemoteAddr)
-		utils.ErrorJSON(w, errors.New("invalid credentials"), http.StatusUnauthorized)
-		return
+	if h.LoginProtectionService != nil {
+		if err := h.LoginProtectionService.RecordSuccess(ctx, requestPayload.Email, appmiddleware.ClientIP(r)); err != nil {
+			logger.Warn("failed to reset login attempt counter",
+				"error", err,
+				"email", requestPayload.Email,
+				"method", "AuthHandler.Authenticate")
+		}
 	}
 
 	// Generate JWT tokens
-	accessToken, refreshToken, err := h.TokenService.GenerateTokens(context.Background(), user)
+	accessToken, refreshToken, err := h.TokenService.GenerateTokens(ctx, user, requestPayload.DeviceID)
 	if err != nil {
-		slog.Error("failed to generate tokens during authentication",
+		logger.Error("failed to generate tokens during authentication",
 			"error", err,
 			"email", user.Email,
 			"user_id", user.ID,
-			"method", "AuthHandler.Authenticate",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Authenticate")
 		utils.ErrorJSON(w, errors.New("failed to generate authentication tokens"), http.StatusInternalServerError)
 		return
 	}
 
+	// An empty refreshToken means GenerateTokens issued an mfa_challenge
+	// token instead of a real pair: the password step passed, but the
+	// caller still needs to complete /mfa/verify before getting one.
+	if refreshToken == "" {
+		logger.Info("mfa challenge issued", "user_id", user.ID, "method", "AuthHandler.Authenticate")
+
+		payload := utils.JsonResponse{
+			Error:   false,
+			Message: "MFA verification required",
+			Data: models.MFAChallengeResponse{
+				MFARequired:    true,
+				ChallengeToken: accessToken,
+				ExpiresIn:      int64(models.DefaultMFAChallengeLifetime.Seconds()),
+			},
+		}
+		_ = utils.WriteJSON(w, payload, http.StatusOK)
+		return
+	}
+
+	// Record the session so it's visible via /sessions and so
+	// MaxActiveSessions can be enforced by evicting the oldest session.
+	if h.SessionService != nil {
+		refreshClaims, claimsErr := h.TokenService.ValidateToken(ctx, refreshToken)
+		if claimsErr != nil {
+			logger.Warn("failed to inspect refresh token for session recording",
+				"error", claimsErr,
+				"user_id", user.ID,
+				"method", "AuthHandler.Authenticate")
+		} else if err := h.SessionService.RecordSession(ctx, user.ID, requestPayload.DeviceID, refreshClaims.ID, r.UserAgent(), r.RemoteAddr, h.MaxActiveSessions); err != nil {
+			logger.Warn("failed to record session",
+				"error", err,
+				"user_id", user.ID,
+				"method", "AuthHandler.Authenticate")
+		}
+	}
+
 	// Update user's last login timestamp
 	user.LastLogin = time.Now()
-	if err := h.UserService.Update(context.Background(), *user); err != nil {
+	if err := h.UserService.Update(ctx, *user); err != nil {
 		// Log error but don't fail the authentication
-		slog.Warn("failed to update last login time",
+		logger.Warn("failed to update last login time",
 			"error", err,
 			"user_id", user.ID,
 			"method", "AuthHandler.Authenticate")
 	}
 
-	slog.Info("user authenticated successfully",
+	logger.Info("user authenticated successfully",
 		"email", user.Email,
 		"user_id", user.ID,
-		"method", "AuthHandler.Authenticate",
-		"remote_addr", r.RemoteAddr)
+		"method", "AuthHandler.Authenticate")
 
 	// Create token response following OAuth2/JWT standards
 	tokenResponse := models.TokenResponse{
@@ -141,20 +203,37 @@ func (h *AuthHandler) Authenticate(w http.ResponseWriter, r *http.Request) {
 	_ = utils.WriteJSON(w, payload, http.StatusOK)
 }
 
+// recordLoginFailure best-effort records a failed login against
+// LoginProtectionService; it never fails the caller's response.
+func (h *AuthHandler) recordLoginFailure(ctx context.Context, email, remoteIP string) {
+	if h.LoginProtectionService == nil {
+		return
+	}
+	if err := h.LoginProtectionService.RecordFailure(ctx, email, remoteIP); err != nil {
+		appmiddleware.LoggerFromContext(ctx).Warn("failed to record login failure",
+			"error", err,
+			"email", email,
+			"method", "AuthHandler.recordLoginFailure")
+	}
+}
+
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	var requestPayload struct {
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		Email     string `json:"email"`
-		Password  string `json:"password"`
+		FirstName         string `json:"first_name"`
+		LastName          string `json:"last_name"`
+		Email             string `json:"email"`
+		Password          string `json:"password"`
+		RegistrationToken string `json:"registration_token"`
 	}
 
 	err := utils.ReadJSON(w, r, &requestPayload)
 	if err != nil {
-		slog.Error("failed to read JSON payload for registration",
+		logger.Error("failed to read JSON payload for registration",
 			"error", err,
-			"method", "AuthHandler.Register",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Register")
 		utils.ErrorJSON(w, err, http.StatusBadRequest)
 		return
 	}
@@ -167,77 +246,93 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		requestPayload.Password,
 	)
 	if err != nil {
-		slog.Warn("registration validation failed",
+		logger.Warn("registration validation failed",
 			"error", err.Error(),
-			"method", "AuthHandler.Register",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Register")
 		utils.ErrorJSON(w, err, http.StatusBadRequest)
 		return
 	}
 
 	// Check if user already exists
-	existingUser, err := h.UserService.GetByEmail(context.Background(), requestPayload.Email)
+	existingUser, err := h.UserService.GetByEmail(ctx, requestPayload.Email)
 	if err == nil && existingUser != nil {
-		slog.Warn("registration attempt with existing email",
+		logger.Warn("registration attempt with existing email",
 			"email", requestPayload.Email,
-			"method", "AuthHandler.Register",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Register")
 		utils.ErrorJSON(w, errors.New("user with this email already exists"), http.StatusConflict)
 		return
 	}
 
+	// If registration is gated by an invite, redeem it before creating the
+	// account; the token may pre-assign a role (e.g. "editor").
+	role := "user"
+	if h.RegistrationTokenService != nil {
+		if requestPayload.RegistrationToken == "" {
+			utils.ErrorJSON(w, errors.New("registration_token is required"), http.StatusBadRequest)
+			return
+		}
+
+		redeemed, err := h.RegistrationTokenService.Redeem(ctx, requestPayload.RegistrationToken)
+		if err != nil {
+			logger.Warn("registration attempted with invalid invite token",
+				"error", err,
+				"method", "AuthHandler.Register")
+			utils.ErrorJSON(w, errors.New("invalid or expired registration token"), http.StatusForbidden)
+			return
+		}
+		if redeemed.PendingRole != "" {
+			role = redeemed.PendingRole
+		}
+	}
+
 	// Create user model
 	usr := models.User{
 		FirstName: requestPayload.FirstName,
 		LastName:  requestPayload.LastName,
 		Email:     requestPayload.Email,
 		Password:  requestPayload.Password,
-		Role:      "user", // Default role
+		Role:      role,
 		IsActive:  true,
 	}
 
-	newUserID, err := h.UserService.Insert(context.Background(), usr)
+	newUserID, err := h.UserService.Insert(ctx, usr)
 	if err != nil {
-		slog.Error("failed to insert new user during registration",
+		logger.Error("failed to insert new user during registration",
 			"error", err,
 			"email", usr.Email,
 			"first_name", usr.FirstName,
 			"last_name", usr.LastName,
-			"method", "AuthHandler.Register",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Register")
 		utils.ErrorJSON(w, errors.New("failed to create user account"), http.StatusInternalServerError)
 		return
 	}
 
 	// Get the created user to generate tokens
-	newUser, err := h.UserService.GetByID(context.Background(), newUserID)
+	newUser, err := h.UserService.GetByID(ctx, newUserID)
 	if err != nil {
-		slog.Error("failed to retrieve newly created user",
+		logger.Error("failed to retrieve newly created user",
 			"error", err,
 			"user_id", newUserID,
-			"method", "AuthHandler.Register",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Register")
 		utils.ErrorJSON(w, errors.New("failed to complete user registration"), http.StatusInternalServerError)
 		return
 	}
 
 	// Generate JWT tokens for the new user (auto-login after registration)
-	accessToken, refreshToken, err := h.TokenService.GenerateTokens(context.Background(), newUser)
+	accessToken, refreshToken, err := h.TokenService.GenerateTokens(ctx, newUser, "")
 	if err != nil {
-		slog.Error("failed to generate tokens during registration",
+		logger.Error("failed to generate tokens during registration",
 			"error", err,
 			"email", newUser.Email,
 			"user_id", newUser.ID,
-			"method", "AuthHandler.Register",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Register")
 		// Don't fail registration, just log the user in manually later
-		slog.Info("new user registered successfully (without auto-login)",
+		logger.Info("new user registered successfully (without auto-login)",
 			"email", usr.Email,
 			"user_id", newUserID,
 			"first_name", usr.FirstName,
 			"last_name", usr.LastName,
-			"method", "AuthHandler.Register",
-			"remote_addr", r.RemoteAddr)
+			"method", "AuthHandler.Register")
 
 		payload := utils.JsonResponse{
 			Error:   false,
@@ -248,13 +343,12 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.Info("new user registered and authenticated successfully",
+	logger.Info("new user registered and authenticated successfully",
 		"email", usr.Email,
 		"user_id", newUserID,
 		"first_name", usr.FirstName,
 		"last_name", usr.LastName,
-		"method", "AuthHandler.Register",
-		"remote_addr", r.RemoteAddr)
+		"method", "AuthHandler.Register")
 
 	// Create token response for auto-login
 	tokenResponse := models.TokenResponse{
@@ -274,3 +368,441 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	_ = utils.WriteJSON(w, payload, http.StatusCreated)
 }
+
+// RefreshToken exchanges a valid refresh token for a new access token.
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload models.RefreshTokenRequest
+
+	err := utils.ReadJSON(w, r, &requestPayload)
+	if err != nil {
+		logger.Error("failed to read JSON payload for token refresh",
+			"error", err,
+			"method", "AuthHandler.RefreshToken")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.RefreshToken == "" {
+		logger.Warn("token refresh attempt with missing refresh token",
+			"method", "AuthHandler.RefreshToken")
+		utils.ErrorJSON(w, errors.New("refresh_token is required"), http.StatusBadRequest)
+		return
+	}
+
+	accessToken, err := h.TokenService.RefreshAccessToken(ctx, requestPayload.RefreshToken)
+	if err != nil {
+		logger.Warn("failed to refresh access token",
+			"error", err,
+			"method", "AuthHandler.RefreshToken")
+		utils.ErrorJSON(w, errors.New("invalid or expired refresh token"), http.StatusUnauthorized)
+		return
+	}
+
+	logger.Info("access token refreshed successfully",
+		"method", "AuthHandler.RefreshToken")
+
+	tokenResponse := models.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   models.DefaultTokenType,
+		ExpiresIn:   int64(models.DefaultAccessTokenLifetime.Seconds()),
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Successfully refreshed access token",
+		Data:    tokenResponse,
+	}
+
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// RotateRefresh exchanges a refresh token for a brand-new access+refresh
+// pair, rotating the old refresh token's jti. A refresh token presented a
+// second time after it was already rotated is treated as stolen: its
+// whole token family is revoked and the request is rejected.
+func (h *AuthHandler) RotateRefresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload models.RefreshTokenRequest
+
+	err := utils.ReadJSON(w, r, &requestPayload)
+	if err != nil {
+		logger.Error("failed to read JSON payload for refresh token rotation",
+			"error", err,
+			"method", "AuthHandler.RotateRefresh")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.RefreshToken == "" {
+		logger.Warn("refresh token rotation attempt with missing refresh token",
+			"method", "AuthHandler.RotateRefresh")
+		utils.ErrorJSON(w, errors.New("refresh_token is required"), http.StatusBadRequest)
+		return
+	}
+
+	accessToken, refreshToken, err := h.TokenService.RotateRefreshToken(ctx, requestPayload.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshTokenReused) {
+			logger.Warn("refresh token reuse detected, token family revoked",
+				"method", "AuthHandler.RotateRefresh")
+			utils.ErrorJSON(w, err, http.StatusUnauthorized)
+			return
+		}
+		logger.Warn("failed to rotate refresh token",
+			"error", err,
+			"method", "AuthHandler.RotateRefresh")
+		utils.ErrorJSON(w, errors.New("invalid or expired refresh token"), http.StatusUnauthorized)
+		return
+	}
+
+	logger.Info("refresh token rotated successfully",
+		"method", "AuthHandler.RotateRefresh")
+
+	tokenResponse := models.TokenResponse{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        models.DefaultTokenType,
+		ExpiresIn:        int64(models.DefaultAccessTokenLifetime.Seconds()),
+		RefreshExpiresIn: int64(models.DefaultRefreshTokenLifetime.Seconds()),
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Successfully rotated refresh token",
+		Data:    tokenResponse,
+	}
+
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// ListActiveTokens returns every token recorded for the authenticated
+// user, so a client can show active sessions and let the user revoke any
+// of them individually.
+func (h *AuthHandler) ListActiveTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("missing authentication claims"), http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.TokenService.GetAllTokensForUser(ctx, strconv.FormatInt(claims.UserID, 10))
+	if err != nil {
+		logger.Error("failed to list tokens for user",
+			"error", err,
+			"method", "AuthHandler.ListActiveTokens")
+		utils.ErrorJSON(w, errors.New("failed to list active tokens"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data:  tokens,
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// RevokeActiveToken revokes a single token, by ID, belonging to the
+// authenticated user.
+func (h *AuthHandler) RevokeActiveToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("missing authentication claims"), http.StatusUnauthorized)
+		return
+	}
+
+	tokenID := chi.URLParam(r, "id")
+	if tokenID == "" {
+		utils.ErrorJSON(w, errors.New("token id is required"), http.StatusBadRequest)
+		return
+	}
+
+	metadata, err := h.TokenService.GetTokenMetadata(ctx, tokenID)
+	if err != nil || metadata.UserID != strconv.FormatInt(claims.UserID, 10) {
+		utils.ErrorJSON(w, errors.New("token not found"), http.StatusNotFound)
+		return
+	}
+
+	if err := h.TokenService.RevokeTokenByID(ctx, tokenID); err != nil {
+		logger.Error("failed to revoke active token",
+			"error", err,
+			"token_id", tokenID,
+			"method", "AuthHandler.RevokeActiveToken")
+		utils.ErrorJSON(w, errors.New("failed to revoke token"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Token revoked",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// RevokeToken marks an access or refresh token as revoked so it can no longer be used.
+func (h *AuthHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload models.TokenRevocationRequest
+
+	err := utils.ReadJSON(w, r, &requestPayload)
+	if err != nil {
+		logger.Error("failed to read JSON payload for token revocation",
+			"error", err,
+			"method", "AuthHandler.RevokeToken")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Token == "" {
+		logger.Warn("token revocation attempt with missing token",
+			"method", "AuthHandler.RevokeToken")
+		utils.ErrorJSON(w, errors.New("token is required"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.TokenService.RevokeToken(ctx, requestPayload.Token); err != nil {
+		logger.Warn("failed to revoke token",
+			"error", err,
+			"method", "AuthHandler.RevokeToken")
+		utils.ErrorJSON(w, errors.New("failed to revoke token"), http.StatusBadRequest)
+		return
+	}
+
+	logger.Info("token revoked successfully",
+		"method", "AuthHandler.RevokeToken")
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Token successfully revoked",
+	}
+
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// Revoke implements RFC 7009 token revocation: it accepts either an access
+// or refresh token (token_type_hint is accepted but not required, since
+// TokenStore blacklists by jti regardless of type) and blacklists it.
+func (h *AuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload models.TokenRevocationRequest
+
+	err := utils.ReadJSON(w, r, &requestPayload)
+	if err != nil {
+		logger.Error("failed to read JSON payload for token revocation",
+			"error", err,
+			"method", "AuthHandler.Revoke")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Token == "" {
+		logger.Warn("token revocation attempt with missing token",
+			"method", "AuthHandler.Revoke")
+		utils.ErrorJSON(w, errors.New("token is required"), http.StatusBadRequest)
+		return
+	}
+
+	// Per RFC 7009, revoking a token that's already invalid or unknown is
+	// not an error: the client's goal (the token no longer being valid) is
+	// already satisfied.
+	if err := h.TokenService.RevokeToken(ctx, requestPayload.Token); err != nil {
+		logger.Warn("failed to revoke token",
+			"error", err,
+			"token_type_hint", requestPayload.TokenType,
+			"method", "AuthHandler.Revoke")
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Token successfully revoked",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// Logout ends the caller's current session: it blacklists the access
+// token's jti (taken from the validated claims RequireAuth already placed
+// on the request context) and, if a refresh token is supplied, its paired
+// refresh token as well.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var requestPayload struct {
+		RefreshToken string `json:"refresh_token,omitempty"`
+	}
+	_ = utils.ReadJSON(w, r, &requestPayload)
+
+	if err := h.TokenService.RevokeTokenByID(ctx, claims.ID); err != nil {
+		logger.Warn("failed to revoke access token on logout",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "AuthHandler.Logout")
+	}
+
+	if requestPayload.RefreshToken != "" {
+		if err := h.TokenService.RevokeToken(ctx, requestPayload.RefreshToken); err != nil {
+			logger.Warn("failed to revoke refresh token on logout",
+				"error", err,
+				"user_id", claims.UserID,
+				"method", "AuthHandler.Logout")
+		}
+	}
+
+	logger.Info("user logged out", "user_id", claims.UserID, "method", "AuthHandler.Logout")
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Successfully logged out",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// Reauthenticate lets an already-authenticated caller re-prove their
+// password to get a step-up access token, one whose AuthTime claim is
+// reset to now instead of inherited from their original login. It's meant
+// for gating sensitive actions (changing email, rotating credentials)
+// behind a recent-password check without forcing a full logout/login or
+// disturbing the caller's refresh token/session.
+func (h *AuthHandler) Reauthenticate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var requestPayload struct {
+		Password string `json:"password"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for reauthentication",
+			"error", err,
+			"method", "AuthHandler.Reauthenticate")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Password == "" {
+		utils.ErrorJSON(w, errors.New("password is required"), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.UserService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		logger.Error("failed to get user for reauthentication",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "AuthHandler.Reauthenticate")
+		utils.ErrorJSON(w, errors.New("invalid credentials"), http.StatusUnauthorized)
+		return
+	}
+
+	valid, err := h.UserService.PasswordMatches(ctx, user, requestPayload.Password)
+	if err != nil || !valid {
+		if err != nil {
+			logger.Error("error checking password during reauthentication",
+				"error", err,
+				"user_id", claims.UserID,
+				"method", "AuthHandler.Reauthenticate")
+		}
+		utils.ErrorJSON(w, errors.New("invalid credentials"), http.StatusUnauthorized)
+		return
+	}
+
+	accessToken, err := h.TokenService.Reauthenticate(ctx, user, claims.DeviceID)
+	if err != nil {
+		logger.Error("failed to reauthenticate",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "AuthHandler.Reauthenticate")
+		utils.ErrorJSON(w, errors.New("failed to reauthenticate"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("user reauthenticated successfully", "user_id", claims.UserID, "method", "AuthHandler.Reauthenticate")
+
+	tokenResponse := models.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   models.DefaultTokenType,
+		ExpiresIn:   int64(models.DefaultAccessTokenLifetime.Seconds()),
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Successfully reauthenticated",
+		Data:    tokenResponse,
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// IntrospectToken reports whether a token is currently valid, per the stored revocation state.
+func (h *AuthHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload struct {
+		Token string `json:"token"`
+	}
+
+	err := utils.ReadJSON(w, r, &requestPayload)
+	if err != nil {
+		logger.Error("failed to read JSON payload for token introspection",
+			"error", err,
+			"method", "AuthHandler.IntrospectToken")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Token == "" {
+		utils.ErrorJSON(w, errors.New("token is required"), http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.TokenService.ValidateToken(ctx, requestPayload.Token)
+	if err != nil {
+		logger.Info("introspected token is not valid",
+			"error", err,
+			"method", "AuthHandler.IntrospectToken")
+
+		payload := utils.JsonResponse{
+			Error: false,
+			Data:  models.TokenValidationResponse{Valid: false, Error: err.Error()},
+		}
+		_ = utils.WriteJSON(w, payload, http.StatusOK)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data: models.TokenValidationResponse{
+			Valid:     true,
+			ExpiresAt: claims.ExpiresAt.Time,
+			Claims:    claims,
+		},
+	}
+
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/internal/authserver"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+)
+
+// AuthServerHandler exposes the standard OIDC endpoints over
+// authserver.Service: /authorize, /token, /userinfo, /revoke, and
+// /end_session. Like DeviceAuthHandler, it stays a thin translation layer
+// - every actual decision (client validation, PKCE, grant dispatch) lives
+// in the service.
+type AuthServerHandler struct {
+	AuthServer   *authserver.Service
+	TokenService services.TokenService
+}
+
+func NewAuthServerHandler(authServer *authserver.Service, tokenService services.TokenService) *AuthServerHandler {
+	return &AuthServerHandler{AuthServer: authServer, TokenService: tokenService}
+}
+
+// Authorize handles GET /authorize. It sits behind RequireAuth: the
+// resource owner approving the request is whoever the bearer token
+// belongs to, since this deployment has no server-rendered login page to
+// redirect an unauthenticated caller to (see authserver.ErrNotAuthenticated).
+func (h *AuthServerHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, authserver.ErrNotAuthenticated, http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	params := authserver.AuthorizeParams{
+		ResponseType:        query.Get("response_type"),
+		ClientID:             query.Get("client_id"),
+		RedirectURI:          query.Get("redirect_uri"),
+		Scope:                query.Get("scope"),
+		State:                query.Get("state"),
+		Nonce:                query.Get("nonce"),
+		CodeChallenge:        query.Get("code_challenge"),
+		CodeChallengeMethod:  query.Get("code_challenge_method"),
+	}
+
+	redirectURL, err := h.AuthServer.Authorize(ctx, claims.UserID, params)
+	if err != nil {
+		logger.Warn("authorize request rejected",
+			"error", err,
+			"client_id", params.ClientID,
+			"method", "AuthServerHandler.Authorize")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+// Token handles POST /token for the authorization_code, client_credentials,
+// and refresh_token grants.
+func (h *AuthServerHandler) Token(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload struct {
+		GrantType    string `json:"grant_type"`
+		Code         string `json:"code"`
+		RedirectURI  string `json:"redirect_uri"`
+		CodeVerifier string `json:"code_verifier"`
+		RefreshToken string `json:"refresh_token"`
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Scope        string `json:"scope"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for token exchange",
+			"error", err,
+			"method", "AuthServerHandler.Token")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.AuthServer.Token(ctx, authserver.TokenParams{
+		GrantType:    requestPayload.GrantType,
+		Code:         requestPayload.Code,
+		RedirectURI:  requestPayload.RedirectURI,
+		CodeVerifier: requestPayload.CodeVerifier,
+		RefreshToken: requestPayload.RefreshToken,
+		ClientID:     requestPayload.ClientID,
+		ClientSecret: requestPayload.ClientSecret,
+		Scope:        requestPayload.Scope,
+	})
+	if err != nil {
+		logger.Warn("token exchange failed",
+			"error", err,
+			"grant_type", requestPayload.GrantType,
+			"method", "AuthServerHandler.Token")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data: models.TokenResponse{
+			AccessToken:  result.AccessToken,
+			RefreshToken: result.RefreshToken,
+			TokenType:    models.DefaultTokenType,
+			ExpiresIn:    result.ExpiresIn,
+		},
+	}
+	if result.IDToken != "" {
+		payload.Data = map[string]interface{}{
+			"access_token":  result.AccessToken,
+			"refresh_token": result.RefreshToken,
+			"id_token":      result.IDToken,
+			"token_type":    models.DefaultTokenType,
+			"expires_in":    result.ExpiresIn,
+		}
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// UserInfo handles GET /userinfo, the OIDC claims endpoint protected by
+// the access token issued alongside an ID token.
+func (h *AuthServerHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	info, err := h.AuthServer.UserInfo(ctx, claims.UserID)
+	if err != nil {
+		logger.Error("failed to load userinfo",
+			"error", err,
+			"method", "AuthServerHandler.UserInfo")
+		utils.ErrorJSON(w, errors.New("failed to load user"), http.StatusInternalServerError)
+		return
+	}
+
+	_ = utils.WriteJSON(w, info, http.StatusOK)
+}
+
+// Revoke handles POST /revoke per RFC 7009, identically to
+// ResourceServerHandler.Revoke: revoking an already-invalid or unknown
+// token is not an error, since the caller's goal is already satisfied.
+func (h *AuthServerHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload models.TokenRevocationRequest
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for revocation",
+			"error", err,
+			"method", "AuthServerHandler.Revoke")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Token == "" {
+		utils.ErrorJSON(w, errors.New("token is required"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.TokenService.RevokeTokenCascade(ctx, requestPayload.Token); err != nil {
+		logger.Warn("failed to revoke token",
+			"error", err,
+			"method", "AuthServerHandler.Revoke")
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Token successfully revoked",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// EndSession handles GET /end_session (RP-Initiated Logout). id_token_hint
+// is accepted but not independently verified before revocation - it's
+// already a token TokenService.RevokeTokenCascade will happily no-op on
+// if it's invalid or foreign, the same tolerance Revoke extends.
+func (h *AuthServerHandler) EndSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	idTokenHint := r.URL.Query().Get("id_token_hint")
+	if idTokenHint != "" {
+		if err := h.TokenService.RevokeTokenCascade(ctx, idTokenHint); err != nil {
+			logger.Warn("failed to revoke session on end_session",
+				"error", err,
+				"method", "AuthServerHandler.EndSession")
+		}
+	}
+
+	if redirectURI := r.URL.Query().Get("post_logout_redirect_uri"); redirectURI != "" {
+		http.Redirect(w, r, redirectURI, http.StatusFound)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "session ended",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
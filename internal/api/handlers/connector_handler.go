@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/eupneart/auth-service/internal/connectors"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// ConnectorHandler dispatches /auth/{connector}/login and
+// /auth/{connector}/callback to the registered external identity connector,
+// then issues local tokens for the linked user.
+type ConnectorHandler struct {
+	Registry     *connectors.Registry
+	TokenService services.TokenService
+}
+
+func NewConnectorHandler(registry *connectors.Registry, tokenService services.TokenService) *ConnectorHandler {
+	return &ConnectorHandler{Registry: registry, TokenService: tokenService}
+}
+
+// connectorAuthCodeURL is implemented by connectors that support the
+// redirect-based OAuth2/OIDC flow.
+type connectorAuthCodeURL interface {
+	AuthCodeURL(state string) string
+}
+
+// Login starts the external login flow, redirecting to the provider when
+// it supports the OAuth2/OIDC redirect dance.
+func (h *ConnectorHandler) Login(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "connector")
+
+	connector, err := h.Registry.Get(name)
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusNotFound)
+		return
+	}
+
+	redirecting, ok := connector.(connectorAuthCodeURL)
+	if !ok {
+		utils.ErrorJSON(w, errors.New("connector does not support redirect login"), http.StatusBadRequest)
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	http.Redirect(w, r, redirecting.AuthCodeURL(state), http.StatusFound)
+}
+
+// Callback completes the external login flow and issues local tokens for
+// the linked user.
+func (h *ConnectorHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "connector")
+
+	connector, err := h.Registry.Get(name)
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusNotFound)
+		return
+	}
+
+	creds := connectors.Credentials{
+		Code:        r.URL.Query().Get("code"),
+		RedirectURI: r.URL.Query().Get("redirect_uri"),
+	}
+
+	user, err := connector.Login(context.Background(), creds)
+	if err != nil {
+		utils.ErrorJSON(w, errors.New("external login failed"), http.StatusUnauthorized)
+		return
+	}
+
+	accessToken, refreshToken, err := h.TokenService.GenerateTokens(context.Background(), user, "")
+	if err != nil {
+		utils.ErrorJSON(w, errors.New("failed to issue tokens"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data: models.TokenResponse{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			TokenType:    models.DefaultTokenType,
+			ExpiresIn:    int64(models.DefaultAccessTokenLifetime.Seconds()),
+		},
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
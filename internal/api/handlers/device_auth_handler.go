@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+)
+
+// DeviceAuthHandler implements the user-agent and polling endpoints of
+// the RFC 8628 device flow. The settings-sensitive VerificationURI is
+// fixed at construction time rather than read per-request.
+type DeviceAuthHandler struct {
+	DeviceAuthService services.DeviceAuthService
+	VerificationURI   string
+}
+
+func NewDeviceAuthHandler(deviceAuthService services.DeviceAuthService, verificationURI string) *DeviceAuthHandler {
+	return &DeviceAuthHandler{DeviceAuthService: deviceAuthService, VerificationURI: verificationURI}
+}
+
+// StartAuthorization handles POST /oauth/device_authorization, the first
+// step a device without a browser takes to begin signing a user in.
+func (h *DeviceAuthHandler) StartAuthorization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload struct {
+		ClientID string `json:"client_id"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for device authorization",
+			"error", err,
+			"method", "DeviceAuthHandler.StartAuthorization")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	rawDeviceCode, auth, err := h.DeviceAuthService.StartAuthorization(ctx, requestPayload.ClientID)
+	if err != nil {
+		logger.Error("failed to start device authorization",
+			"error", err,
+			"method", "DeviceAuthHandler.StartAuthorization")
+		utils.ErrorJSON(w, errors.New("failed to start device authorization"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data: map[string]interface{}{
+			"device_code":      rawDeviceCode,
+			"user_code":        auth.UserCode,
+			"verification_uri": h.VerificationURI,
+			"expires_in":       int64(services.DefaultDeviceAuthLifetime.Seconds()),
+			"interval":         auth.IntervalSeconds,
+		},
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// VerifyUserCode handles POST /oauth/device/verify. It sits behind
+// RequireAuth: the signed-in user approves the device they're looking at
+// a code on by submitting that code here.
+func (h *DeviceAuthHandler) VerifyUserCode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var requestPayload struct {
+		UserCode string `json:"user_code"`
+		Approve  bool   `json:"approve"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for device verification",
+			"error", err,
+			"method", "DeviceAuthHandler.VerifyUserCode")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.UserCode == "" {
+		utils.ErrorJSON(w, errors.New("user_code is required"), http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if requestPayload.Approve {
+		err = h.DeviceAuthService.VerifyUserCode(ctx, requestPayload.UserCode, claims.UserID)
+	} else {
+		err = h.DeviceAuthService.DenyUserCode(ctx, requestPayload.UserCode)
+	}
+	if err != nil {
+		logger.Warn("device verification failed",
+			"error", err,
+			"method", "DeviceAuthHandler.VerifyUserCode")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "device authorization updated",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// PollToken handles the device_code grant on the token endpoint: a device
+// polls with the device_code it received from StartAuthorization until
+// it gets back a token pair or access_denied.
+func (h *DeviceAuthHandler) PollToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload struct {
+		GrantType  string `json:"grant_type"`
+		DeviceCode string `json:"device_code"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for device token poll",
+			"error", err,
+			"method", "DeviceAuthHandler.PollToken")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.GrantType != models.DeviceCodeGrantType {
+		utils.ErrorJSON(w, errors.New("unsupported_grant_type"), http.StatusBadRequest)
+		return
+	}
+	if requestPayload.DeviceCode == "" {
+		utils.ErrorJSON(w, errors.New("device_code is required"), http.StatusBadRequest)
+		return
+	}
+
+	accessToken, refreshToken, err := h.DeviceAuthService.PollToken(ctx, requestPayload.DeviceCode)
+	if err != nil {
+		status, body := deviceAuthPollError(err)
+		logger.Info("device token poll not yet satisfied",
+			"error", err,
+			"method", "DeviceAuthHandler.PollToken")
+		utils.ErrorJSON(w, errors.New(body), status)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data: models.TokenResponse{
+			AccessToken:      accessToken,
+			RefreshToken:     refreshToken,
+			TokenType:        models.DefaultTokenType,
+			ExpiresIn:        int64(models.DefaultAccessTokenLifetime.Seconds()),
+			RefreshExpiresIn: int64(models.DefaultRefreshTokenLifetime.Seconds()),
+		},
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// deviceAuthPollError maps a DeviceAuthService.PollToken error onto the
+// RFC 8628 section 3.5 error code and HTTP status the polling client
+// expects.
+func deviceAuthPollError(err error) (status int, code string) {
+	switch err {
+	case services.ErrDeviceAuthPending:
+		return http.StatusBadRequest, "authorization_pending"
+	case services.ErrDeviceAuthSlowDown:
+		return http.StatusBadRequest, "slow_down"
+	case services.ErrDeviceAuthAccessDenied:
+		return http.StatusForbidden, "access_denied"
+	case services.ErrDeviceAuthExpired:
+		return http.StatusBadRequest, "expired_token"
+	default:
+		return http.StatusBadRequest, "invalid_grant"
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/eupneart/auth-service/internal/keys"
+	"github.com/eupneart/auth-service/internal/services/revocationdigest"
+	"github.com/eupneart/auth-service/utils"
+)
+
+// DiscoveryHandler serves the OIDC discovery document and the JWKS endpoint
+// resource servers use to verify tokens without sharing a secret.
+type DiscoveryHandler struct {
+	Issuer     string
+	KeyManager *keys.Manager
+
+	// RevocationDigestService is optional: when set, RevocationDigest is
+	// mounted at GET /.well-known/revocation-digest.
+	RevocationDigestService *revocationdigest.Service
+
+	// AuthServerEnabled is set when the embedded OIDC authorization server
+	// (internal/authserver) is mounted, so OpenIDConfiguration can
+	// advertise its endpoints instead of only the discovery/JWKS pair
+	// every deployment already has.
+	AuthServerEnabled bool
+}
+
+func NewDiscoveryHandler(issuer string, keyManager *keys.Manager) *DiscoveryHandler {
+	return &DiscoveryHandler{Issuer: issuer, KeyManager: keyManager}
+}
+
+// OpenIDConfiguration serves GET /.well-known/openid-configuration.
+func (h *DiscoveryHandler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	config := map[string]interface{}{
+		"issuer":                                h.Issuer,
+		"jwks_uri":                              fmt.Sprintf("%s/.well-known/jwks.json", h.Issuer),
+		"token_endpoint":                        fmt.Sprintf("%s/token/refresh", h.Issuer),
+		"revocation_endpoint":                   fmt.Sprintf("%s/auth/revoke", h.Issuer),
+		"introspection_endpoint":                fmt.Sprintf("%s/token/introspect", h.Issuer),
+		"id_token_signing_alg_values_supported": []string{"RS256", "ES256"},
+		"subject_types_supported":               []string{"public"},
+		"response_types_supported":              []string{"code", "token"},
+	}
+
+	// The embedded authorization server overrides token_endpoint with its
+	// own grant-dispatching /token and adds the endpoints only it serves.
+	if h.AuthServerEnabled {
+		config["authorization_endpoint"] = fmt.Sprintf("%s/authorize", h.Issuer)
+		config["token_endpoint"] = fmt.Sprintf("%s/token", h.Issuer)
+		config["userinfo_endpoint"] = fmt.Sprintf("%s/userinfo", h.Issuer)
+		config["end_session_endpoint"] = fmt.Sprintf("%s/end_session", h.Issuer)
+		config["grant_types_supported"] = []string{"authorization_code", "client_credentials", "refresh_token"}
+		config["scopes_supported"] = []string{"openid", "email", "profile"}
+		config["code_challenge_methods_supported"] = []string{"S256"}
+	}
+
+	_ = utils.WriteJSON(w, config, http.StatusOK)
+}
+
+// JWKS serves GET /.well-known/jwks.json with the current and still-valid
+// previous signing keys.
+func (h *DiscoveryHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	_ = utils.WriteJSON(w, h.KeyManager.JWKS(), http.StatusOK)
+}
+
+// RevocationDigest serves GET /.well-known/revocation-digest: a signed,
+// versioned Bloom filter over currently revoked, non-expired token IDs.
+// Resource servers are expected to cache it by ETag and check membership
+// locally, only calling back to the token introspection endpoint on a
+// (possibly false-positive) hit.
+func (h *DiscoveryHandler) RevocationDigest(w http.ResponseWriter, r *http.Request) {
+	digest, err := h.RevocationDigestService.Current()
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("ETag", fmt.Sprintf(`"rev-%d"`, digest.Version))
+	_ = utils.WriteJSON(w, digest, http.StatusOK)
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+)
+
+// MFAHandler exposes TOTP enrollment and the /mfa/verify step that
+// exchanges an mfa_challenge token (issued by Authenticate when MFA is
+// enabled for the user) plus a code for the real access+refresh pair.
+type MFAHandler struct {
+	UserService  *services.UserService
+	TokenService services.TokenService
+	MFAService   services.MFAService
+
+	// LoginProtectionService is optional: when set, Verify rejects
+	// requests from a locked-out (mfa:<user_id>, remote IP) pair the same
+	// way Authenticate guards against password brute-forcing.
+	LoginProtectionService services.LoginProtectionService
+}
+
+func NewMFAHandler(userService *services.UserService, tokenService services.TokenService, mfaService services.MFAService) *MFAHandler {
+	return &MFAHandler{
+		UserService:  userService,
+		TokenService: tokenService,
+		MFAService:   mfaService,
+	}
+}
+
+// lockoutKey namespaces the (email, remote IP) key LoginProtectionService
+// tracks so a run of bad TOTP codes locks out MFA verification without
+// touching the password-lockout counter for the same user.
+func lockoutKey(userID int64) string {
+	return fmt.Sprintf("mfa:%d", userID)
+}
+
+// Enroll starts TOTP enrollment for the authenticated caller, returning
+// an otpauth:// URI for their authenticator app and a set of recovery
+// codes shown exactly once. MFA doesn't actually turn on until the caller
+// verifies a code generated from the new secret via Verify.
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	otpauthURI, recoveryCodes, err := h.MFAService.Enroll(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		logger.Error("failed to start mfa enrollment",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "MFAHandler.Enroll")
+		utils.ErrorJSON(w, errors.New("failed to start mfa enrollment"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("mfa enrollment started", "user_id", claims.UserID, "method", "MFAHandler.Enroll")
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Scan the QR code for otpauth_uri, then confirm with a code via /mfa/verify",
+		Data: map[string]interface{}{
+			"otpauth_uri":    otpauthURI,
+			"recovery_codes": recoveryCodes,
+		},
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// Verify exchanges a challenge_token from Authenticate plus a TOTP (or
+// recovery) code for the real access+refresh pair.
+func (h *MFAHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code,omitempty"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for mfa verification",
+			"error", err,
+			"method", "MFAHandler.Verify")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.ChallengeToken == "" || (requestPayload.Code == "" && requestPayload.RecoveryCode == "") {
+		utils.ErrorJSON(w, errors.New("challenge_token and code (or recovery_code) are required"), http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.TokenService.ValidateToken(ctx, requestPayload.ChallengeToken)
+	if err != nil || claims.TokenType != models.TokenTypeMFAChallenge {
+		utils.ErrorJSON(w, errors.New("invalid or expired challenge token"), http.StatusUnauthorized)
+		return
+	}
+
+	if h.LoginProtectionService != nil {
+		key := lockoutKey(claims.UserID)
+		locked, retryAfter, lockErr := h.LoginProtectionService.CheckLocked(ctx, key, appmiddleware.ClientIP(r))
+		if lockErr != nil {
+			logger.Error("failed to check mfa lockout status",
+				"error", lockErr,
+				"user_id", claims.UserID,
+				"method", "MFAHandler.Verify")
+		} else if locked {
+			w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+			utils.ErrorJSON(w, services.ErrAccountLocked, http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	var verified bool
+	if requestPayload.RecoveryCode != "" {
+		verified, err = h.MFAService.VerifyRecoveryCode(ctx, claims.UserID, requestPayload.RecoveryCode)
+	} else {
+		verified, err = h.MFAService.Verify(ctx, claims.UserID, requestPayload.Code)
+	}
+	if err != nil {
+		logger.Error("failed to verify mfa code",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "MFAHandler.Verify")
+		utils.ErrorJSON(w, errors.New("failed to verify mfa code"), http.StatusInternalServerError)
+		return
+	}
+
+	if !verified {
+		logger.Warn("invalid mfa code", "user_id", claims.UserID, "method", "MFAHandler.Verify")
+		if h.LoginProtectionService != nil {
+			if err := h.LoginProtectionService.RecordFailure(ctx, lockoutKey(claims.UserID), appmiddleware.ClientIP(r)); err != nil {
+				logger.Warn("failed to record mfa failure", "error", err, "method", "MFAHandler.Verify")
+			}
+		}
+		utils.ErrorJSON(w, errors.New("invalid mfa code"), http.StatusUnauthorized)
+		return
+	}
+
+	if h.LoginProtectionService != nil {
+		if err := h.LoginProtectionService.RecordSuccess(ctx, lockoutKey(claims.UserID), appmiddleware.ClientIP(r)); err != nil {
+			logger.Warn("failed to reset mfa lockout counter", "error", err, "method", "MFAHandler.Verify")
+		}
+	}
+
+	user, err := h.UserService.GetByID(ctx, claims.UserID)
+	if err != nil {
+		logger.Error("failed to get user for mfa completion",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "MFAHandler.Verify")
+		utils.ErrorJSON(w, errors.New("failed to complete mfa verification"), http.StatusInternalServerError)
+		return
+	}
+
+	accessToken, refreshToken, err := h.TokenService.CompleteMFAChallenge(ctx, user, claims.DeviceID)
+	if err != nil {
+		logger.Error("failed to issue tokens after mfa verification",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "MFAHandler.Verify")
+		utils.ErrorJSON(w, errors.New("failed to complete mfa verification"), http.StatusInternalServerError)
+		return
+	}
+
+	// The challenge token has served its purpose; revoke it so it can't
+	// be replayed against /mfa/verify a second time.
+	if err := h.TokenService.RevokeTokenByID(ctx, claims.ID); err != nil {
+		logger.Warn("failed to revoke spent mfa challenge token",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "MFAHandler.Verify")
+	}
+
+	logger.Info("mfa verification succeeded", "user_id", claims.UserID, "method", "MFAHandler.Verify")
+
+	tokenResponse := models.TokenResponse{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        models.DefaultTokenType,
+		ExpiresIn:        int64(models.DefaultAccessTokenLifetime.Seconds()),
+		RefreshExpiresIn: int64(models.DefaultRefreshTokenLifetime.Seconds()),
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Successfully verified mfa code",
+		Data:    tokenResponse,
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// Disable turns MFA off for the authenticated caller, requiring a valid
+// TOTP or recovery code first so a stolen access token alone can't
+// downgrade an account's protection.
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var requestPayload struct {
+		Code string `json:"code"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for mfa disable",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "MFAHandler.Disable")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Code == "" {
+		utils.ErrorJSON(w, errors.New("code is required"), http.StatusBadRequest)
+		return
+	}
+
+	disabled, err := h.MFAService.Disable(ctx, claims.UserID, requestPayload.Code)
+	if err != nil {
+		if errors.Is(err, services.ErrMFANotEnrolled) {
+			utils.ErrorJSON(w, err, http.StatusBadRequest)
+			return
+		}
+		logger.Error("failed to disable mfa",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "MFAHandler.Disable")
+		utils.ErrorJSON(w, errors.New("failed to disable mfa"), http.StatusInternalServerError)
+		return
+	}
+
+	if !disabled {
+		utils.ErrorJSON(w, errors.New("invalid mfa code"), http.StatusUnauthorized)
+		return
+	}
+
+	logger.Info("mfa disabled", "user_id", claims.UserID, "method", "MFAHandler.Disable")
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "MFA has been disabled",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
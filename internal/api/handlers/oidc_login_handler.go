@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/oidcauth"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// oidcFlowCookieTTL bounds how long a user has between hitting /login and
+// completing /callback before the PKCE state is discarded.
+const oidcFlowCookieTTL = 5 * time.Minute
+
+// OIDCLoginHandler drives the authorization-code-with-PKCE OIDC social
+// login flow, independent of the simpler connectors-based OAuth2 routes.
+type OIDCLoginHandler struct {
+	Manager      *oidcauth.Manager
+	UserService  *services.UserService
+	TokenService services.TokenService
+}
+
+func NewOIDCLoginHandler(manager *oidcauth.Manager, userService *services.UserService, tokenService services.TokenService) *OIDCLoginHandler {
+	return &OIDCLoginHandler{
+		Manager:      manager,
+		UserService:  userService,
+		TokenService: tokenService,
+	}
+}
+
+// Login redirects to the named provider's authorization endpoint, stashing
+// the CSRF state and PKCE verifier in short-lived cookies for Callback to
+// read back.
+func (h *OIDCLoginHandler) Login(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	name := chi.URLParam(r, "provider")
+	provider, err := h.Manager.Get(name)
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusNotFound)
+		return
+	}
+
+	state, err := randomFlowValue()
+	if err != nil {
+		logger.Error("failed to generate oidc state", "error", err, "provider", name, "method", "OIDCLoginHandler.Login")
+		utils.ErrorJSON(w, errors.New("failed to start login"), http.StatusInternalServerError)
+		return
+	}
+
+	codeVerifier, err := randomFlowValue()
+	if err != nil {
+		logger.Error("failed to generate pkce verifier", "error", err, "provider", name, "method", "OIDCLoginHandler.Login")
+		utils.ErrorJSON(w, errors.New("failed to start login"), http.StatusInternalServerError)
+		return
+	}
+
+	setFlowCookie(w, "oidc_state", state)
+	setFlowCookie(w, "oidc_verifier", codeVerifier)
+
+	http.Redirect(w, r, provider.AuthCodeURL(state, codeVerifier), http.StatusFound)
+}
+
+// Callback completes the flow: it validates state, exchanges the
+// authorization code, verifies the ID token, links or provisions a local
+// user, and issues this service's own access/refresh tokens.
+func (h *OIDCLoginHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	name := chi.URLParam(r, "provider")
+	provider, err := h.Manager.Get(name)
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusNotFound)
+		return
+	}
+
+	stateCookie, err := r.Cookie("oidc_state")
+	if err != nil || stateCookie.Value == "" || stateCookie.Value != r.URL.Query().Get("state") {
+		logger.Warn("oidc callback with missing or mismatched state", "provider", name, "method", "OIDCLoginHandler.Callback")
+		utils.ErrorJSON(w, errors.New("invalid login state"), http.StatusBadRequest)
+		return
+	}
+
+	verifierCookie, err := r.Cookie("oidc_verifier")
+	if err != nil || verifierCookie.Value == "" {
+		logger.Warn("oidc callback with missing pkce verifier", "provider", name, "method", "OIDCLoginHandler.Callback")
+		utils.ErrorJSON(w, errors.New("invalid login state"), http.StatusBadRequest)
+		return
+	}
+	clearFlowCookie(w, "oidc_state")
+	clearFlowCookie(w, "oidc_verifier")
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		utils.ErrorJSON(w, errors.New("code is required"), http.StatusBadRequest)
+		return
+	}
+
+	claims, err := provider.Exchange(ctx, code, verifierCookie.Value)
+	if err != nil {
+		logger.Warn("oidc token exchange failed", "error", err, "provider", name, "method", "OIDCLoginHandler.Callback")
+		utils.ErrorJSON(w, errors.New("external login failed"), http.StatusUnauthorized)
+		return
+	}
+
+	if !provider.IsDomainAllowed(claims.Email) {
+		logger.Warn("oidc login rejected by domain allow-list", "provider", name, "email", claims.Email, "method", "OIDCLoginHandler.Callback")
+		utils.ErrorJSON(w, errors.New("this account is not permitted to sign in"), http.StatusForbidden)
+		return
+	}
+
+	user, err := h.UserService.LinkIdentity(ctx, provider.Name(), claims.Subject, claims.Email, claims.EmailVerified, claims.GivenName, claims.FamilyName, provider.AllowSignup())
+	if err != nil {
+		logger.Warn("failed to link oidc identity", "error", err, "provider", name, "method", "OIDCLoginHandler.Callback")
+		utils.ErrorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	accessToken, refreshToken, err := h.TokenService.GenerateTokens(ctx, user, "")
+	if err != nil {
+		logger.Error("failed to generate tokens after oidc login", "error", err, "user_id", user.ID, "method", "OIDCLoginHandler.Callback")
+		utils.ErrorJSON(w, errors.New("failed to issue tokens"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("user authenticated via oidc provider", "provider", name, "user_id", user.ID, "method", "OIDCLoginHandler.Callback")
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data: models.TokenResponse{
+			AccessToken:      accessToken,
+			RefreshToken:     refreshToken,
+			TokenType:        models.DefaultTokenType,
+			ExpiresIn:        int64(models.DefaultAccessTokenLifetime.Seconds()),
+			RefreshExpiresIn: int64(models.DefaultRefreshTokenLifetime.Seconds()),
+		},
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+func setFlowCookie(w http.ResponseWriter, name, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/auth/oidc",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(oidcFlowCookieTTL.Seconds()),
+	})
+}
+
+func clearFlowCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/auth/oidc",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	})
+}
+
+// randomFlowValue returns a cryptographically random, hex-encoded value
+// suitable for use as OIDC state or a PKCE code verifier.
+func randomFlowValue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+)
+
+// PasswordResetHandler exposes the forgot-password / reset-password flow.
+type PasswordResetHandler struct {
+	PasswordResetService services.PasswordResetService
+}
+
+func NewPasswordResetHandler(passwordResetService services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{PasswordResetService: passwordResetService}
+}
+
+// ForgotPassword emails a reset link for the given address. It always
+// responds 200, whether or not the address belongs to an account, so the
+// response can't be used to enumerate registered users.
+func (h *PasswordResetHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload struct {
+		Email string `json:"email"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for forgot-password",
+			"error", err,
+			"method", "PasswordResetHandler.ForgotPassword")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Email == "" {
+		utils.ErrorJSON(w, errors.New("email is required"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.PasswordResetService.RequestReset(ctx, requestPayload.Email); err != nil {
+		logger.Error("failed to process forgot-password request",
+			"error", err,
+			"email", requestPayload.Email,
+			"method", "PasswordResetHandler.ForgotPassword")
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "If an account exists for that email, a reset link has been sent",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// ResetPassword redeems a reset token and sets the user's new password.
+func (h *PasswordResetHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload struct {
+		ResetToken  string `json:"reset_token"`
+		NewPassword string `json:"new_password"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for reset-password",
+			"error", err,
+			"method", "PasswordResetHandler.ResetPassword")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.ResetToken == "" || requestPayload.NewPassword == "" {
+		utils.ErrorJSON(w, errors.New("reset_token and new_password are required"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.PasswordResetService.ResetPassword(ctx, requestPayload.ResetToken, requestPayload.NewPassword); err != nil {
+		logger.Warn("failed to reset password",
+			"error", err,
+			"method", "PasswordResetHandler.ResetPassword")
+		utils.ErrorJSON(w, errors.New("invalid or expired reset token"), http.StatusBadRequest)
+		return
+	}
+
+	logger.Info("password reset via token", "method", "PasswordResetHandler.ResetPassword")
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Password has been reset successfully",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
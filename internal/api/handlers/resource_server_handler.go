@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+)
+
+// ResourceServerHandler exposes OAuth2-style introspection and revocation
+// endpoints for downstream resource servers, mounted behind
+// appmiddleware.RequireResourceServerAuth rather than a user's bearer
+// token. Unlike AuthHandler.IntrospectToken (this service's own internal
+// response shape), Introspect returns the RFC 7662 standard field names
+// so non-Go resource servers can verify tokens without depending on that
+// internal shape.
+type ResourceServerHandler struct {
+	TokenService services.TokenService
+}
+
+func NewResourceServerHandler(tokenService services.TokenService) *ResourceServerHandler {
+	return &ResourceServerHandler{TokenService: tokenService}
+}
+
+// Introspect implements RFC 7662: POST {"token": "..."}, returning
+// {"active": false} for any revoked, expired, or malformed token without
+// revealing which.
+func (h *ResourceServerHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload struct {
+		Token string `json:"token"`
+	}
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for resource server introspection",
+			"error", err,
+			"method", "ResourceServerHandler.Introspect")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Token == "" {
+		_ = utils.WriteJSON(w, models.IntrospectionResponse{Active: false}, http.StatusOK)
+		return
+	}
+
+	resp, err := h.TokenService.Introspect(ctx, requestPayload.Token)
+	if err != nil {
+		logger.Error("introspection failed",
+			"error", err,
+			"method", "ResourceServerHandler.Introspect")
+		_ = utils.WriteJSON(w, models.IntrospectionResponse{Active: false}, http.StatusOK)
+		return
+	}
+
+	_ = utils.WriteJSON(w, resp, http.StatusOK)
+}
+
+// Revoke implements RFC 7009 for resource servers: accepts either an
+// access or refresh token. Handing it a refresh token also revokes every
+// token rotated from the same family, so a resource server that catches
+// a compromised refresh token can shut down the whole chain in one call.
+func (h *ResourceServerHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	var requestPayload models.TokenRevocationRequest
+
+	if err := utils.ReadJSON(w, r, &requestPayload); err != nil {
+		logger.Error("failed to read JSON payload for resource server revocation",
+			"error", err,
+			"method", "ResourceServerHandler.Revoke")
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if requestPayload.Token == "" {
+		utils.ErrorJSON(w, errors.New("token is required"), http.StatusBadRequest)
+		return
+	}
+
+	// Per RFC 7009, revoking a token that's already invalid or unknown is
+	// not an error: the caller's goal is already satisfied.
+	if err := h.TokenService.RevokeTokenCascade(ctx, requestPayload.Token); err != nil {
+		logger.Warn("failed to revoke token",
+			"error", err,
+			"token_type_hint", requestPayload.TokenType,
+			"method", "ResourceServerHandler.Revoke")
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "Token successfully revoked",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionHandler exposes the authenticated user's own active sessions,
+// recorded by SessionService on every successful Authenticate.
+type SessionHandler struct {
+	SessionService services.SessionService
+}
+
+func NewSessionHandler(sessionService services.SessionService) *SessionHandler {
+	return &SessionHandler{SessionService: sessionService}
+}
+
+// ListSessions returns every active session for the authenticated user.
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.SessionService.ListSessions(ctx, claims.UserID)
+	if err != nil {
+		logger.Error("failed to list sessions",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "SessionHandler.ListSessions")
+		utils.ErrorJSON(w, errors.New("failed to list sessions"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error: false,
+		Data:  sessions,
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// RevokeSession ends a single session owned by the authenticated user.
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	if sessionID == "" {
+		utils.ErrorJSON(w, errors.New("session id is required"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.SessionService.RevokeSession(ctx, claims.UserID, sessionID); err != nil {
+		logger.Warn("failed to revoke session",
+			"error", err,
+			"session_id", sessionID,
+			"user_id", claims.UserID,
+			"method", "SessionHandler.RevokeSession")
+		utils.ErrorJSON(w, errors.New("failed to revoke session"), http.StatusBadRequest)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "session revoked",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
+
+// RevokeAllSessions logs the authenticated user out of every device except
+// the one making this request.
+func (h *SessionHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	claims := appmiddleware.ClaimsFromContext(ctx)
+	if claims == nil {
+		utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.SessionService.RevokeAllExcept(ctx, claims.UserID, claims.DeviceID); err != nil {
+		logger.Error("failed to revoke other sessions",
+			"error", err,
+			"user_id", claims.UserID,
+			"method", "SessionHandler.RevokeAllSessions")
+		utils.ErrorJSON(w, errors.New("failed to revoke sessions"), http.StatusInternalServerError)
+		return
+	}
+
+	payload := utils.JsonResponse{
+		Error:   false,
+		Message: "all other sessions revoked",
+	}
+	_ = utils.WriteJSON(w, payload, http.StatusOK)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+// parseTokenFilterGroups parses the ?filter= query param into one or more
+// TokenFilters, field:value pairs joined by AND within a group and
+// groups joined by OR, in the spirit of Boundary's daemon search handler
+// grammar. An empty raw string yields a single zero-valued filter (no
+// restriction).
+func parseTokenFilterGroups(raw string) ([]models.TokenFilter, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return []models.TokenFilter{{}}, nil
+	}
+
+	var groups []models.TokenFilter
+	for _, groupStr := range strings.Split(raw, " OR ") {
+		filter, err := parseTokenFilterGroup(groupStr)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, filter)
+	}
+
+	return groups, nil
+}
+
+func parseTokenFilterGroup(groupStr string) (models.TokenFilter, error) {
+	var filter models.TokenFilter
+
+	for _, clause := range strings.Split(groupStr, " AND ") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+
+		field, value, ok := strings.Cut(clause, ":")
+		if !ok {
+			return filter, fmt.Errorf("malformed filter clause %q, expected field:value", clause)
+		}
+
+		if err := applyTokenFilterClause(&filter, strings.TrimSpace(field), strings.TrimSpace(value)); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+func applyTokenFilterClause(filter *models.TokenFilter, field, value string) error {
+	switch field {
+	case "user_id":
+		filter.UserID = value
+	case "token_type":
+		filter.TokenType = value
+	case "device_id":
+		filter.DeviceID = value
+	case "client_id":
+		filter.ClientID = value
+	case "is_revoked":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("is_revoked: %w", err)
+		}
+		filter.IsRevoked = &b
+	case "created_before":
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("created_before: %w", err)
+		}
+		filter.CreatedBefore = t
+	case "created_after":
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("created_after: %w", err)
+		}
+		filter.CreatedAfter = t
+	case "expires_before":
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("expires_before: %w", err)
+		}
+		filter.ExpiresBefore = t
+	case "expires_after":
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("expires_after: %w", err)
+		}
+		filter.ExpiresAfter = t
+	default:
+		return fmt.Errorf("unknown filter field %q", field)
+	}
+
+	return nil
+}
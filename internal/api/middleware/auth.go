@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/utils"
+)
+
+type claimsCtxKey struct{}
+
+// RequireAuth validates the bearer access token on incoming requests via
+// tokenService and stores its claims in the request context, retrievable
+// with ClaimsFromContext. Requests without a valid token are rejected
+// before reaching the wrapped handler.
+func RequireAuth(tokenService services.TokenService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			token := strings.TrimPrefix(header, "Bearer ")
+			if header == "" || token == header {
+				utils.ErrorJSON(w, errMissingBearerToken, http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := tokenService.ValidateToken(r.Context(), token)
+			if err != nil {
+				utils.ErrorJSON(w, err, http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsCtxKey{}, claims)
+			ctx = WithUserID(ctx, claims.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClaimsFromContext returns the claims stored by RequireAuth, or nil if
+// the request never passed through it.
+func ClaimsFromContext(ctx context.Context) *models.Claims {
+	claims, _ := ctx.Value(claimsCtxKey{}).(*models.Claims)
+	return claims
+}
+
+// RequireRole gates a route on the caller's token carrying one of roles,
+// and must be mounted after RequireAuth so ClaimsFromContext has
+// something to check. A caller authenticated but lacking an allowed role
+// gets 403, not 401 - they proved who they are, just not that they're
+// allowed here.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := ClaimsFromContext(r.Context())
+			if claims == nil {
+				utils.ErrorJSON(w, errMissingBearerToken, http.StatusUnauthorized)
+				return
+			}
+
+			for _, role := range roles {
+				if claims.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			utils.ErrorJSON(w, errInsufficientRole, http.StatusForbidden)
+		})
+	}
+}
+
+var errInsufficientRole = &insufficientRoleError{}
+
+type insufficientRoleError struct{}
+
+func (e *insufficientRoleError) Error() string {
+	return "caller's role is not permitted to access this resource"
+}
+
+var errMissingBearerToken = &missingBearerTokenError{}
+
+type missingBearerTokenError struct{}
+
+func (e *missingBearerTokenError) Error() string {
+	return "missing or malformed Authorization header"
+}
+
+// RequireResourceServerAuth gates endpoints meant for downstream resource
+// servers (e.g. RFC 7662 introspection) rather than end users: the caller
+// must present one of secrets via X-Resource-Server-Secret instead of a
+// user's bearer token. Each resource server can be issued its own entry
+// so one can be rotated out without affecting the others.
+func RequireResourceServerAuth(secrets []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented := r.Header.Get("X-Resource-Server-Secret")
+			if presented == "" || !anySecretMatches(secrets, presented) {
+				utils.ErrorJSON(w, errInvalidResourceServerSecret, http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func anySecretMatches(secrets []string, presented string) bool {
+	for _, secret := range secrets {
+		if secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(presented)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+var errInvalidResourceServerSecret = &invalidResourceServerSecretError{}
+
+type invalidResourceServerSecretError struct{}
+
+func (e *invalidResourceServerSecretError) Error() string {
+	return "missing or invalid resource server secret"
+}
@@ -0,0 +1,68 @@
+// Package middleware holds chi middleware shared across the API's routes.
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type loggerCtxKey struct{}
+type remoteIPCtxKey struct{}
+
+// RequestLogger injects a child logger carrying request_id, remote_ip,
+// method, and path into the request context, so downstream handlers and
+// services can log with per-request correlation via LoggerFromContext.
+func RequestLogger(base *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestLogger := base.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("remote_ip", r.RemoteAddr),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+			)
+
+			ctx := WithLogger(r.Context(), requestLogger)
+			ctx = WithRemoteIP(ctx, r.RemoteAddr)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WithLogger returns a context carrying logger, retrievable via LoggerFromContext.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, logger)
+}
+
+// WithUserID annotates the request-scoped logger already in ctx with the
+// authenticated user's ID, once known.
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return WithLogger(ctx, LoggerFromContext(ctx).With(slog.Int64("user_id", userID)))
+}
+
+// WithRemoteIP annotates ctx with the request's remote address, so
+// service-layer code that needs it for auditing (see repositories.WithActor)
+// doesn't have to thread it through every method signature.
+func WithRemoteIP(ctx context.Context, remoteIP string) context.Context {
+	return context.WithValue(ctx, remoteIPCtxKey{}, remoteIP)
+}
+
+// RemoteIPFromContext returns the remote address stored by WithRemoteIP,
+// or "" if none was set (e.g. in tests or background jobs).
+func RemoteIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(remoteIPCtxKey{}).(string)
+	return ip
+}
+
+// LoggerFromContext returns the request-scoped logger stored by
+// RequestLogger, or slog.Default() if none is present (e.g. in tests or
+// background jobs that don't run through the HTTP middleware chain).
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerCtxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
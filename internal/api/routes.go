@@ -1,9 +1,12 @@
 package api
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/eupneart/auth-service/internal/api/handlers"
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
+	"github.com/eupneart/auth-service/pkg/env"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -22,14 +25,151 @@ func (s *Server) Routes() http.Handler {
 		MaxAge:           300,
 	}))
 
+  mux.Use(middleware.RequestID)
+
+  logger := env.Logger
+  if logger == nil {
+    logger = slog.Default()
+  }
+  mux.Use(appmiddleware.RequestLogger(logger))
+
   mux.Use(middleware.Heartbeat("/ping"))
 
+  // Global IP-based token bucket, ahead of per-account lockout, to blunt
+  // distributed brute-force guessing.
+  mux.Use(appmiddleware.RateLimit(
+    float64(env.GetEnvAsInt("RATE_LIMIT_RPS", 5)),
+    env.GetEnvAsInt("RATE_LIMIT_BURST", 10),
+  ))
+
   // create auth handler
-  authHandler := handlers.NewAuthHandler(s.UserService) 
+  authHandler := handlers.NewAuthHandler(s.UserService, s.TokenService)
+  if s.SessionService != nil {
+    authHandler.SessionService = s.SessionService
+    authHandler.MaxActiveSessions = env.GetEnvAsInt("MAX_ACTIVE_SESSIONS", 5)
+  }
+  if s.LoginProtectionService != nil {
+    authHandler.LoginProtectionService = s.LoginProtectionService
+  }
+  if s.RegistrationTokenService != nil {
+    authHandler.RegistrationTokenService = s.RegistrationTokenService
+  }
+  discoveryHandler := handlers.NewDiscoveryHandler(s.Settings.JWTIssuer, s.KeyManager)
 
   mux.Post("/authenticate", authHandler.Authenticate)
   mux.Post("/register", authHandler.Register)
-  
+
+  mux.Post("/token/refresh", authHandler.RefreshToken)
+  mux.Post("/token/revoke", authHandler.RevokeToken)
+  mux.Post("/token/introspect", authHandler.IntrospectToken)
+
+  mux.Post("/auth/revoke", authHandler.Revoke)
+  mux.Post("/auth/refresh", authHandler.RotateRefresh)
+  mux.Group(func(protected chi.Router) {
+    protected.Use(appmiddleware.RequireAuth(s.TokenService))
+    protected.Post("/auth/logout", authHandler.Logout)
+    protected.Post("/auth/reauthenticate", authHandler.Reauthenticate)
+    protected.Get("/auth/sessions", authHandler.ListActiveTokens)
+    protected.Delete("/auth/sessions/{id}", authHandler.RevokeActiveToken)
+  })
+
+  if s.PasswordResetService != nil {
+    passwordResetHandler := handlers.NewPasswordResetHandler(s.PasswordResetService)
+    mux.Post("/auth/forgot-password", passwordResetHandler.ForgotPassword)
+    mux.Post("/auth/reset-password", passwordResetHandler.ResetPassword)
+  }
+
+  if s.RegistrationTokenService != nil || s.TokenAdminService != nil {
+    adminHandler := handlers.NewAdminHandler(s.RegistrationTokenService)
+    adminHandler.TokenAdminService = s.TokenAdminService
+    mux.Group(func(protected chi.Router) {
+      protected.Use(appmiddleware.RequireAuth(s.TokenService))
+      protected.Use(appmiddleware.RequireRole("admin"))
+      if s.RegistrationTokenService != nil {
+        protected.Post("/admin/registration_tokens", adminHandler.IssueRegistrationToken)
+        protected.Get("/admin/registration_tokens", adminHandler.ListRegistrationTokens)
+        protected.Put("/admin/registration_tokens/{id}", adminHandler.UpdateRegistrationToken)
+        protected.Delete("/admin/registration_tokens/{id}", adminHandler.DeleteRegistrationToken)
+      }
+      if s.TokenAdminService != nil {
+        protected.Get("/admin/tokens", adminHandler.ListTokens)
+      }
+    })
+  }
+
+  if s.MFAService != nil {
+    mfaHandler := handlers.NewMFAHandler(s.UserService, s.TokenService, s.MFAService)
+    mfaHandler.LoginProtectionService = s.LoginProtectionService
+    mux.Post("/mfa/verify", mfaHandler.Verify)
+    mux.Group(func(protected chi.Router) {
+      protected.Use(appmiddleware.RequireAuth(s.TokenService))
+      protected.Post("/mfa/enroll", mfaHandler.Enroll)
+      protected.Post("/mfa/disable", mfaHandler.Disable)
+    })
+  }
+
+  if len(s.Settings.ResourceServerSecrets) > 0 {
+    resourceServerHandler := handlers.NewResourceServerHandler(s.TokenService)
+    mux.Group(func(rs chi.Router) {
+      rs.Use(appmiddleware.RequireResourceServerAuth(s.Settings.ResourceServerSecrets))
+      rs.Post("/oauth/introspect", resourceServerHandler.Introspect)
+      rs.Post("/oauth/revoke", resourceServerHandler.Revoke)
+    })
+  }
+
+  if s.DeviceAuthService != nil {
+    deviceAuthHandler := handlers.NewDeviceAuthHandler(s.DeviceAuthService, s.Settings.AppBaseURL+"/device")
+    mux.Post("/oauth/device_authorization", deviceAuthHandler.StartAuthorization)
+    mux.Post("/oauth/token", deviceAuthHandler.PollToken)
+    mux.Group(func(protected chi.Router) {
+      protected.Use(appmiddleware.RequireAuth(s.TokenService))
+      protected.Post("/oauth/device/verify", deviceAuthHandler.VerifyUserCode)
+    })
+  }
+
+  if s.AuthServer != nil {
+    authServerHandler := handlers.NewAuthServerHandler(s.AuthServer, s.TokenService)
+    mux.Post("/token", authServerHandler.Token)
+    mux.Post("/revoke", authServerHandler.Revoke)
+    mux.Get("/end_session", authServerHandler.EndSession)
+    mux.Group(func(protected chi.Router) {
+      protected.Use(appmiddleware.RequireAuth(s.TokenService))
+      protected.Get("/authorize", authServerHandler.Authorize)
+      protected.Get("/userinfo", authServerHandler.UserInfo)
+    })
+  }
+
+  discoveryHandler.RevocationDigestService = s.RevocationDigestService
+  discoveryHandler.AuthServerEnabled = s.AuthServer != nil
+
+  mux.Get("/.well-known/openid-configuration", discoveryHandler.OpenIDConfiguration)
+  mux.Get("/.well-known/jwks.json", discoveryHandler.JWKS)
+  if s.RevocationDigestService != nil {
+    mux.Get("/.well-known/revocation-digest", discoveryHandler.RevocationDigest)
+  }
+
+  if s.Connectors != nil {
+    connectorHandler := handlers.NewConnectorHandler(s.Connectors, s.TokenService)
+    mux.Get("/auth/{connector}/login", connectorHandler.Login)
+    mux.Get("/auth/{connector}/callback", connectorHandler.Callback)
+  }
+
+  if s.OIDCLoginManager != nil {
+    oidcLoginHandler := handlers.NewOIDCLoginHandler(s.OIDCLoginManager, s.UserService, s.TokenService)
+    mux.Get("/auth/oidc/{provider}/login", oidcLoginHandler.Login)
+    mux.Get("/auth/oidc/{provider}/callback", oidcLoginHandler.Callback)
+  }
+
+  if s.SessionService != nil {
+    sessionHandler := handlers.NewSessionHandler(s.SessionService)
+    mux.Group(func(protected chi.Router) {
+      protected.Use(appmiddleware.RequireAuth(s.TokenService))
+      protected.Get("/sessions", sessionHandler.ListSessions)
+      protected.Delete("/sessions/{id}", sessionHandler.RevokeSession)
+      protected.Delete("/sessions", sessionHandler.RevokeAllSessions)
+    })
+  }
+
   return mux
 }
 
@@ -3,7 +3,12 @@ package api
 import (
 	"net/http"
 
+	"github.com/eupneart/auth-service/internal/authserver"
+	"github.com/eupneart/auth-service/internal/connectors"
+	"github.com/eupneart/auth-service/internal/keys"
+	"github.com/eupneart/auth-service/internal/oidcauth"
 	"github.com/eupneart/auth-service/internal/services"
+	"github.com/eupneart/auth-service/internal/services/revocationdigest"
 	"github.com/eupneart/auth-service/pkg/env"
 )
 
@@ -11,13 +16,37 @@ type Server struct {
   Settings *env.EnvConfig
   UserService *services.UserService
   TokenService services.TokenService
+  KeyManager *keys.Manager
+  Connectors *connectors.Registry
+  SessionService services.SessionService
+  PasswordResetService services.PasswordResetService
+  OIDCLoginManager *oidcauth.Manager
+  LoginProtectionService services.LoginProtectionService
+  RegistrationTokenService services.RegistrationTokenService
+  DeviceAuthService services.DeviceAuthService
+  TokenAdminService services.TokenAdminService
+  RevocationDigestService *revocationdigest.Service
+  MFAService services.MFAService
+  AuthServer *authserver.Service
 }
 
-func NewServer(settings *env.EnvConfig, userService *services.UserService, tokenService services.TokenService) *Server {
+func NewServer(settings *env.EnvConfig, userService *services.UserService, tokenService services.TokenService, keyManager *keys.Manager, connectorRegistry *connectors.Registry, sessionService services.SessionService, passwordResetService services.PasswordResetService, oidcLoginManager *oidcauth.Manager, loginProtectionService services.LoginProtectionService, registrationTokenService services.RegistrationTokenService, deviceAuthService services.DeviceAuthService, tokenAdminService services.TokenAdminService, revocationDigestService *revocationdigest.Service, mfaService services.MFAService, authServer *authserver.Service) *Server {
   return &Server{
     Settings: settings,
     UserService: userService,
     TokenService: tokenService,
+    KeyManager: keyManager,
+    Connectors: connectorRegistry,
+    SessionService: sessionService,
+    PasswordResetService: passwordResetService,
+    OIDCLoginManager: oidcLoginManager,
+    LoginProtectionService: loginProtectionService,
+    RegistrationTokenService: registrationTokenService,
+    DeviceAuthService: deviceAuthService,
+    TokenAdminService: tokenAdminService,
+    RevocationDigestService: revocationDigestService,
+    MFAService: mfaService,
+    AuthServer: authServer,
   }
 }
 
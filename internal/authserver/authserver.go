@@ -0,0 +1,338 @@
+// Package authserver implements an embedded OIDC authorization server:
+// the authorization-code (with mandatory PKCE), client-credentials, and
+// refresh-token grants, layered on top of the existing UserService,
+// TokenService, and keys.Manager rather than introducing a second
+// credential or signing stack of its own. Service holds only business
+// logic; the HTTP layer lives in
+// internal/api/handlers/authserver_handler.go, the same split
+// DeviceAuthService/DeviceAuthHandler and MFAService/MFAHandler already
+// use.
+package authserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/keys"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+	"github.com/eupneart/auth-service/internal/services"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Grant types accepted at /token.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+// DefaultAuthorizationCodeLifetime is how long a code from Authorize stays
+// redeemable at /token, per RFC 6749 section 4.1.2's "SHOULD expire shortly".
+const DefaultAuthorizationCodeLifetime = 1 * time.Minute
+
+// Service implements the business logic behind the standard OIDC
+// endpoints. It delegates everything it can to existing services rather
+// than re-implementing token issuance or user lookup: GenerateTokens for
+// the access/refresh pair, RotateRefreshToken for the refresh grant, and
+// RevokeTokenCascade for /revoke.
+type Service struct {
+	clientRepo   repositories.OAuthClientRepository
+	requestRepo  repositories.AuthRequestRepository
+	userService  *services.UserService
+	tokenService services.TokenService
+	keyManager   *keys.Manager
+	issuer       string
+	logger       *slog.Logger
+}
+
+// NewService creates a Service. issuer is stamped into every ID token's
+// iss claim and should match the rest of the deployment's JWT_ISSUER.
+func NewService(clientRepo repositories.OAuthClientRepository, requestRepo repositories.AuthRequestRepository, userService *services.UserService, tokenService services.TokenService, keyManager *keys.Manager, issuer string, logger *slog.Logger) *Service {
+	return &Service{
+		clientRepo:   clientRepo,
+		requestRepo:  requestRepo,
+		userService:  userService,
+		tokenService: tokenService,
+		keyManager:   keyManager,
+		issuer:       issuer,
+		logger:       logger,
+	}
+}
+
+// AuthorizeParams is the parsed query string of a GET /authorize request.
+type AuthorizeParams struct {
+	ResponseType        string
+	ClientID             string
+	RedirectURI          string
+	Scope                string
+	State                string
+	Nonce                string
+	CodeChallenge        string
+	CodeChallengeMethod  string
+}
+
+// Authorize validates params against the registered client and, if they
+// check out, issues a single-use authorization code on behalf of userID -
+// the resource owner the caller has already authenticated via a bearer
+// token (see ErrNotAuthenticated's doc comment for why that's what gates
+// this instead of a login redirect). It returns the redirect_uri to send
+// the caller's user-agent to, with code and state appended as query
+// parameters.
+func (s *Service) Authorize(ctx context.Context, userID int64, params AuthorizeParams) (string, error) {
+	if params.ResponseType != "code" {
+		return "", ErrUnsupportedResponseType
+	}
+
+	client, err := s.clientRepo.GetByClientID(ctx, params.ClientID)
+	if err != nil {
+		s.logger.Warn("authorize attempted with unknown client_id",
+			"error", err,
+			"client_id", params.ClientID,
+			"method", "authserver.Service.Authorize")
+		return "", ErrClientNotFound
+	}
+	if !client.AllowsRedirectURI(params.RedirectURI) {
+		return "", ErrRedirectURINotAllowed
+	}
+	if !client.AllowsGrantType(GrantTypeAuthorizationCode) {
+		return "", ErrGrantTypeNotAllowed
+	}
+	if params.CodeChallenge == "" {
+		return "", ErrPKCERequired
+	}
+	if params.CodeChallengeMethod != CodeChallengeMethodS256 {
+		return "", ErrUnsupportedCodeChallengeMethod
+	}
+
+	rawCode, err := randomAuthorizationCode(32)
+	if err != nil {
+		s.logger.Error("failed to generate authorization code",
+			"error", err,
+			"method", "authserver.Service.Authorize")
+		return "", fmt.Errorf("generating authorization code: %w", err)
+	}
+
+	req := &models.AuthorizationRequest{
+		ID:                  uuid.New().String(),
+		CodeHash:            hashAuthorizationCode(rawCode),
+		ClientID:            params.ClientID,
+		UserID:              userID,
+		RedirectURI:         params.RedirectURI,
+		Scope:               params.Scope,
+		State:               params.State,
+		Nonce:               params.Nonce,
+		CodeChallenge:       params.CodeChallenge,
+		CodeChallengeMethod: params.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(DefaultAuthorizationCodeLifetime),
+		CreatedAt:           time.Now(),
+	}
+
+	if err := s.requestRepo.Create(ctx, req); err != nil {
+		s.logger.Error("failed to store authorization request",
+			"error", err,
+			"method", "authserver.Service.Authorize")
+		return "", fmt.Errorf("storing authorization request: %w", err)
+	}
+
+	s.logger.Info("issued authorization code",
+		"auth_request_id", req.ID,
+		"client_id", params.ClientID,
+		"user_id", userID)
+
+	redirect, err := url.Parse(params.RedirectURI)
+	if err != nil {
+		return "", fmt.Errorf("parsing redirect_uri: %w", err)
+	}
+	query := redirect.Query()
+	query.Set("code", rawCode)
+	if params.State != "" {
+		query.Set("state", params.State)
+	}
+	redirect.RawQuery = query.Encode()
+
+	return redirect.String(), nil
+}
+
+// TokenParams is the parsed body of a /token request; which fields apply
+// depends on GrantType.
+type TokenParams struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+}
+
+// TokenResult is the successful response from Token. IDToken is only set
+// for the authorization_code grant; RefreshToken is unset for
+// client_credentials, which issues no refresh token to rotate.
+type TokenResult struct {
+	AccessToken  string
+	RefreshToken string
+	IDToken      string
+	ExpiresIn    int64
+}
+
+// Token dispatches params.GrantType to the matching grant handler,
+// mirroring how DeviceAuthHandler.PollToken dispatches on grant_type at
+// the HTTP layer - except here the dispatch is part of Service itself,
+// since /token serves three unrelated grants behind one endpoint.
+func (s *Service) Token(ctx context.Context, params TokenParams) (*TokenResult, error) {
+	switch params.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(ctx, params)
+	case GrantTypeClientCredentials:
+		return s.exchangeClientCredentials(ctx, params)
+	case GrantTypeRefreshToken:
+		return s.exchangeRefreshToken(ctx, params)
+	default:
+		return nil, ErrUnsupportedGrantType
+	}
+}
+
+// exchangeAuthorizationCode redeems a code issued by Authorize for a
+// token pair plus an ID token, verifying PKCE and that the code hasn't
+// already been consumed or expired.
+func (s *Service) exchangeAuthorizationCode(ctx context.Context, params TokenParams) (*TokenResult, error) {
+	req, err := s.requestRepo.GetByCodeHash(ctx, hashAuthorizationCode(params.Code))
+	if err != nil {
+		return nil, ErrInvalidAuthorizationCode
+	}
+	if req.IsExpired() || req.IsConsumed() {
+		return nil, ErrInvalidAuthorizationCode
+	}
+	if req.ClientID != params.ClientID || req.RedirectURI != params.RedirectURI {
+		return nil, ErrInvalidAuthorizationCode
+	}
+	if !verifyPKCE(req.CodeChallenge, params.CodeVerifier) {
+		return nil, ErrPKCEVerificationFailed
+	}
+
+	if err := s.requestRepo.Consume(ctx, req.ID); err != nil {
+		s.logger.Warn("authorization code already consumed",
+			"error", err,
+			"auth_request_id", req.ID,
+			"method", "authserver.Service.exchangeAuthorizationCode")
+		return nil, ErrInvalidAuthorizationCode
+	}
+
+	user, err := s.userService.GetByID(ctx, req.UserID)
+	if err != nil {
+		s.logger.Error("failed to load user for authorization code exchange",
+			"error", err,
+			"auth_request_id", req.ID,
+			"method", "authserver.Service.exchangeAuthorizationCode")
+		return nil, fmt.Errorf("loading user for authorization code: %w", err)
+	}
+
+	accessToken, refreshToken, err := s.tokenService.GenerateTokens(ctx, user, req.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("generating tokens: %w", err)
+	}
+
+	idToken, err := s.signIDToken(user, req.ClientID, req.Nonce, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("signing id token: %w", err)
+	}
+
+	return &TokenResult{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		IDToken:      idToken,
+		ExpiresIn:    int64(models.DefaultAccessTokenLifetime.Seconds()),
+	}, nil
+}
+
+// exchangeClientCredentials authenticates a confidential client against
+// its stored secret hash and issues it a short-lived, user-less access
+// token for the machine-to-machine grant. It is never registered in the
+// TokenStore the way a login-issued token is - it's a self-contained,
+// stateless JWT, the same scoping tradeoff revocationdigest.Service
+// already accepts for any caller willing to trust a signed assertion over
+// a live lookup.
+func (s *Service) exchangeClientCredentials(ctx context.Context, params TokenParams) (*TokenResult, error) {
+	client, err := s.clientRepo.GetByClientID(ctx, params.ClientID)
+	if err != nil {
+		return nil, ErrInvalidClientCredentials
+	}
+	if !client.IsConfidential() || bcrypt.CompareHashAndPassword([]byte(client.ClientSecretHash), []byte(params.ClientSecret)) != nil {
+		return nil, ErrInvalidClientCredentials
+	}
+	if !client.AllowsGrantType(GrantTypeClientCredentials) {
+		return nil, ErrGrantTypeNotAllowed
+	}
+
+	accessToken, err := s.signClientCredentialsToken(params.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("signing client credentials token: %w", err)
+	}
+
+	return &TokenResult{
+		AccessToken: accessToken,
+		ExpiresIn:   int64(DefaultClientCredentialsTokenLifetime.Seconds()),
+	}, nil
+}
+
+// exchangeRefreshToken delegates straight to TokenService.RotateRefreshToken,
+// the same reuse-detection-and-rotation logic every other refresh caller
+// goes through; authserver adds nothing of its own here beyond selecting
+// the grant.
+func (s *Service) exchangeRefreshToken(ctx context.Context, params TokenParams) (*TokenResult, error) {
+	accessToken, newRefreshToken, err := s.tokenService.RotateRefreshToken(ctx, params.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResult{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(models.DefaultAccessTokenLifetime.Seconds()),
+	}, nil
+}
+
+// UserInfo returns the OIDC standard claims for userID, for the
+// /userinfo endpoint. It's deliberately a small, fixed claim set rather
+// than mirroring every models.User field - scope-gated claim sets aren't
+// worth the complexity until a second client actually needs them.
+func (s *Service) UserInfo(ctx context.Context, userID int64) (map[string]interface{}, error) {
+	user, err := s.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"sub":         fmt.Sprintf("%d", user.ID),
+		"email":       user.Email,
+		"given_name":  user.FirstName,
+		"family_name": user.LastName,
+	}, nil
+}
+
+// randomAuthorizationCode returns a cryptographically random, hex-encoded
+// authorization code of n random bytes, mirroring
+// deviceAuthService.randomDeviceCode.
+func randomAuthorizationCode(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashAuthorizationCode returns the hex-encoded SHA-256 hash of a raw
+// authorization code, the only form ever persisted.
+func hashAuthorizationCode(rawCode string) string {
+	sum := sha256.Sum256([]byte(rawCode))
+	return hex.EncodeToString(sum[:])
+}
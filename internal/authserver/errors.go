@@ -0,0 +1,28 @@
+package authserver
+
+import "errors"
+
+var (
+	ErrClientNotFound          = errors.New("oauth client not found")
+	ErrRedirectURINotAllowed   = errors.New("redirect_uri is not registered for this client")
+	ErrGrantTypeNotAllowed     = errors.New("grant_type is not allowed for this client")
+	ErrUnsupportedGrantType    = errors.New("unsupported grant_type")
+	ErrUnsupportedResponseType = errors.New("unsupported response_type")
+
+	// ErrNotAuthenticated is returned by the /authorize handler when no
+	// valid resource owner access token is present. This deployment has no
+	// server-rendered login page to redirect to, so a bearer token is what
+	// gates /authorize instead of a login redirect.
+	ErrNotAuthenticated = errors.New("a valid access token is required to authorize this request")
+
+	ErrPKCERequired                   = errors.New("code_challenge is required")
+	ErrUnsupportedCodeChallengeMethod = errors.New("only the S256 code_challenge_method is supported")
+	ErrInvalidAuthorizationCode       = errors.New("invalid or expired authorization code")
+	ErrPKCEVerificationFailed         = errors.New("code_verifier does not match code_challenge")
+	ErrInvalidClientCredentials       = errors.New("invalid client credentials")
+
+	// ErrSigningKeyNotFound mirrors services.ErrSigningKeyNotFound: the key
+	// manager has no current signing key, which should only happen before
+	// keys.Manager.Init has run.
+	ErrSigningKeyNotFound = errors.New("signing key not found")
+)
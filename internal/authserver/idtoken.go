@@ -0,0 +1,109 @@
+package authserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/keys"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// DefaultIDTokenLifetime matches DefaultAccessTokenLifetime: an ID token
+// is a point-in-time assertion about a login that already happened, so
+// there's no reason for it to outlive the access token issued alongside it.
+const DefaultIDTokenLifetime = 15 * time.Minute
+
+// DefaultClientCredentialsTokenLifetime is how long an access token
+// issued to a client_credentials caller stays valid.
+const DefaultClientCredentialsTokenLifetime = 15 * time.Minute
+
+// sign mints and signs claims with keyManager's current key, exactly
+// mirroring tokenService.sign in internal/services - duplicated here
+// rather than exported from that package, since tokenService keeps
+// signing unexported and scoped to its own token issuance.
+func sign(keyManager *keys.Manager, claims *models.Claims) (string, error) {
+	kp := keyManager.Current()
+	if kp == nil {
+		return "", ErrSigningKeyNotFound
+	}
+
+	method, err := signingMethodFor(kp.Alg)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kp.KID
+
+	return token.SignedString(kp.PrivateKey)
+}
+
+// signingMethodFor maps a keys.Algorithm to its jwt.SigningMethod.
+func signingMethodFor(alg keys.Algorithm) (jwt.SigningMethod, error) {
+	switch alg {
+	case keys.AlgES256:
+		return jwt.SigningMethodES256, nil
+	case keys.AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+}
+
+// signIDToken mints an OIDC ID token asserting user's authentication,
+// carrying at_hash - the left half of accessToken's SHA-256 hash,
+// base64url-encoded - so a client can bind the ID token to the access
+// token it was issued alongside, per OIDC Core section 3.1.3.6.
+func (s *Service) signIDToken(user *models.User, clientID, nonce, accessToken string) (string, error) {
+	now := time.Now()
+
+	sum := sha256.Sum256([]byte(accessToken))
+	atHash := base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+
+	claims := &models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(DefaultIDTokenLifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			Audience:  jwt.ClaimStrings{clientID},
+			ID:        uuid.New().String(),
+		},
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenType: models.TokenTypeIDToken,
+		ClientID:  clientID,
+		Nonce:     nonce,
+		AtHash:    atHash,
+	}
+
+	return sign(s.keyManager, claims)
+}
+
+// signClientCredentialsToken mints a short-lived access token for a
+// client_credentials caller. Subject is the client_id itself - there's
+// no user to speak for in a machine-to-machine exchange.
+func (s *Service) signClientCredentialsToken(clientID string) (string, error) {
+	now := time.Now()
+
+	claims := &models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(DefaultClientCredentialsTokenLifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.issuer,
+			Subject:   clientID,
+			ID:        uuid.New().String(),
+		},
+		TokenType: models.TokenTypeAccess,
+		ClientID:  clientID,
+	}
+
+	return sign(s.keyManager, claims)
+}
@@ -0,0 +1,72 @@
+// Package connectors lets the auth service delegate authentication to
+// external identity providers (OIDC, LDAP, GitHub, ...) behind a single
+// interface, so new providers can be registered without the router needing
+// to know their specifics.
+package connectors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+// Credentials carries whatever a connector needs to complete a login: an
+// OAuth2/OIDC authorization code, LDAP username/password, or similar.
+type Credentials struct {
+	Code         string // OAuth2/OIDC authorization code
+	RedirectURI  string
+	Username     string // LDAP bind username
+	Password     string // LDAP bind password
+	RefreshToken string
+}
+
+// Connector authenticates against a single external identity provider and
+// maps the result onto a local models.User.
+type Connector interface {
+	// Name returns the connector's registration key, e.g. "oidc", "ldap", "github".
+	Name() string
+
+	// Login exchanges Credentials for the external identity and
+	// provisions/links a local user for it.
+	Login(ctx context.Context, creds Credentials) (*models.User, error)
+
+	// Refresh renews the external session using a previously obtained
+	// refresh token, returning the (possibly updated) local user.
+	Refresh(ctx context.Context, refreshToken string) (*models.User, error)
+}
+
+// Registry dispatches to connectors by name so routes can be added without
+// the router knowing about each provider's implementation.
+type Registry struct {
+	connectors map[string]Connector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{connectors: make(map[string]Connector)}
+}
+
+// Register adds a connector under its own Name(). Registering two
+// connectors with the same name is a programming error.
+func (r *Registry) Register(c Connector) {
+	r.connectors[c.Name()] = c
+}
+
+// Get looks up a connector by name, as used to dispatch
+// /auth/{connector}/... requests.
+func (r *Registry) Get(name string) (Connector, error) {
+	c, ok := r.connectors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown connector %q", name)
+	}
+	return c, nil
+}
+
+// Names returns the registration keys of every enabled connector.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.connectors))
+	for name := range r.connectors {
+		names = append(names, name)
+	}
+	return names
+}
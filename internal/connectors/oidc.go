@@ -0,0 +1,129 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig holds the per-connector settings for an upstream OIDC provider,
+// loaded from CONNECTOR_OIDC_* environment variables.
+type OIDCConfig struct {
+	ClientID     string
+	ClientSecret string
+	IssuerURL    string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// oidcConnector logs a user in via the standard OIDC authorization-code flow.
+type oidcConnector struct {
+	cfg        OIDCConfig
+	oauth2Cfg  oauth2.Config
+	userLinker UserLinker
+}
+
+// UserLinker provisions or links a local user for an external identity. It
+// is implemented by the user service so connectors stay decoupled from
+// persistence details. emailVerified must only be true when the connector
+// has actual proof the address belongs to the authenticating caller (a
+// verified IdP claim, or a directory the deployment already trusts) -
+// LinkExternalIdentity refuses to auto-link to an existing account
+// otherwise.
+type UserLinker interface {
+	LinkExternalIdentity(ctx context.Context, provider, externalID, email string, emailVerified bool, firstName, lastName string) (*models.User, error)
+}
+
+func NewOIDCConnector(cfg OIDCConfig, userLinker UserLinker) Connector {
+	return &oidcConnector{
+		cfg: cfg,
+		oauth2Cfg: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  cfg.AuthURL,
+				TokenURL: cfg.TokenURL,
+			},
+		},
+		userLinker: userLinker,
+	}
+}
+
+func (c *oidcConnector) Name() string { return "oidc" }
+
+// AuthCodeURL builds the redirect URL for GET /auth/oidc/login.
+func (c *oidcConnector) AuthCodeURL(state string) string {
+	return c.oauth2Cfg.AuthCodeURL(state)
+}
+
+func (c *oidcConnector) Login(ctx context.Context, creds Credentials) (*models.User, error) {
+	token, err := c.oauth2Cfg.Exchange(ctx, creds.Code)
+	if err != nil {
+		return nil, fmt.Errorf("exchanging oidc authorization code: %w", err)
+	}
+
+	claims, err := c.fetchUserInfo(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.userLinker.LinkExternalIdentity(ctx, c.Name(), claims.Subject, claims.Email, claims.EmailVerified, claims.GivenName, claims.FamilyName)
+}
+
+func (c *oidcConnector) Refresh(ctx context.Context, refreshToken string) (*models.User, error) {
+	token, err := c.oauth2Cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("refreshing oidc session: %w", err)
+	}
+
+	claims, err := c.fetchUserInfo(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.userLinker.LinkExternalIdentity(ctx, c.Name(), claims.Subject, claims.Email, claims.EmailVerified, claims.GivenName, claims.FamilyName)
+}
+
+// userInfoClaims is the subset of the OIDC UserInfo response we need to
+// provision/link a local user.
+type userInfoClaims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+}
+
+func (c *oidcConnector) fetchUserInfo(ctx context.Context, token *oauth2.Token) (*userInfoClaims, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.UserInfoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building userinfo request: %w", err)
+	}
+	token.SetAuthHeader(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling userinfo endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo endpoint returned status %d", resp.StatusCode)
+	}
+
+	var claims userInfoClaims
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("decoding userinfo response: %w", err)
+	}
+
+	return &claims, nil
+}
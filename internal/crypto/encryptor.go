@@ -0,0 +1,89 @@
+// Package crypto provides at-rest encryption for secrets that must be
+// reversible - unlike passwords (hashed via services.PasswordHasher),
+// callers sometimes need the plaintext back, e.g. MFAService validating a
+// TOTP code against the secret it enrolled.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidKeySize is returned by NewAESGCMEncryptor for any key that
+// isn't a valid AES key length (16, 24, or 32 bytes).
+var ErrInvalidKeySize = errors.New("encryption key must be 16, 24, or 32 bytes")
+
+// Encryptor encrypts and decrypts small secrets at rest. Implementations
+// are expected to be safe for concurrent use.
+type Encryptor interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+// AESGCMEncryptor implements Encryptor with AES-GCM: a fresh random nonce
+// per call, prepended to the sealed output and base64-encoded into a
+// single opaque string that fits the text columns callers already store
+// secrets in.
+type AESGCMEncryptor struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMEncryptor builds an AESGCMEncryptor from a raw key, typically
+// loaded from a KMS-managed secret rather than committed alongside the
+// rest of config.
+func NewAESGCMEncryptor(key []byte) (*AESGCMEncryptor, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidKeySize
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating AES cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("creating GCM mode: %w", err)
+	}
+
+	return &AESGCMEncryptor{aead: aead}, nil
+}
+
+// Encrypt seals plaintext and returns it base64-encoded, nonce included.
+func (e *AESGCMEncryptor) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+
+	sealed := e.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt, returning an error if ciphertext was tampered
+// with or wasn't produced by this (or an identically-keyed) Encryptor.
+func (e *AESGCMEncryptor) Decrypt(ciphertext string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decoding ciphertext: %w", err)
+	}
+
+	nonceSize := e.aead.NonceSize()
+	if len(raw) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := raw[:nonceSize], raw[nonceSize:]
+	plaintext, err := e.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting ciphertext: %w", err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,357 @@
+// Package keys manages the signing keys used to issue OIDC tokens,
+// including generation, on-disk persistence, and rotation with an overlap
+// window so tokens signed by a just-retired key keep verifying until it
+// finally expires.
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const keyBits = 2048
+
+// Algorithm identifies which signing algorithm (and therefore which key
+// type) a Manager generates and advertises.
+type Algorithm string
+
+const (
+	AlgRS256 Algorithm = "RS256"
+	AlgES256 Algorithm = "ES256"
+)
+
+// KeyPair is a single signing key with a stable kid used in JWT headers and
+// JWKS output. PrivateKey is either an *rsa.PrivateKey (AlgRS256) or an
+// *ecdsa.PrivateKey (AlgES256); both satisfy crypto.Signer, which is all
+// token signing needs.
+type KeyPair struct {
+	KID        string
+	Alg        Algorithm
+	PrivateKey crypto.Signer
+	CreatedAt  time.Time
+	// RetireAt is when this key stops being advertised/accepted entirely.
+	// Zero while the key is current.
+	RetireAt time.Time
+}
+
+// Manager generates, persists, and rotates signing keys, all of the same
+// Algorithm. At most one key is "current" (used to sign new tokens);
+// retired keys remain valid for verification until their overlap window
+// (Overlap) elapses.
+type Manager struct {
+	mu      sync.RWMutex
+	dir     string
+	overlap time.Duration
+	alg     Algorithm
+
+	current  *KeyPair
+	previous []*KeyPair
+}
+
+// NewManager creates a Manager that persists keys as PEM files under dir,
+// generating alg-typed keys (AlgRS256 or AlgES256). overlap controls how
+// long a rotated-out key still verifies tokens signed with it.
+func NewManager(dir string, overlap time.Duration, alg Algorithm) *Manager {
+	return &Manager{dir: dir, overlap: overlap, alg: alg}
+}
+
+// Init loads any previously persisted keys from disk, or generates a fresh
+// signing key if none exist yet.
+func (m *Manager) Init() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dir != "" {
+		if err := os.MkdirAll(m.dir, 0o700); err != nil {
+			return fmt.Errorf("creating key directory: %w", err)
+		}
+
+		loaded, err := m.loadFromDisk()
+		if err != nil {
+			return fmt.Errorf("loading signing keys: %w", err)
+		}
+		if loaded {
+			return nil
+		}
+	}
+
+	kp, err := generateKeyPair(m.alg)
+	if err != nil {
+		return fmt.Errorf("generating initial signing key: %w", err)
+	}
+
+	m.current = kp
+	return m.persist(kp)
+}
+
+// Current returns the key pair currently used to sign new tokens.
+func (m *Manager) Current() *KeyPair {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
+}
+
+// Lookup returns the key pair (current or still-in-overlap) with the given
+// kid, for verifying a previously issued token.
+func (m *Manager) Lookup(kid string) (*KeyPair, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.current != nil && m.current.KID == kid {
+		return m.current, true
+	}
+	now := time.Now()
+	for _, kp := range m.previous {
+		if kp.KID == kid && kp.RetireAt.After(now) {
+			return kp, true
+		}
+	}
+	return nil, false
+}
+
+// Rotate retires the current key (keeping it valid for verification during
+// the overlap window) and generates a new current key.
+func (m *Manager) Rotate() (*KeyPair, error) {
+	kp, err := generateKeyPair(m.alg)
+	if err != nil {
+		return nil, fmt.Errorf("generating rotated signing key: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.current != nil {
+		m.current.RetireAt = time.Now().Add(m.overlap)
+		m.previous = append(m.previous, m.current)
+	}
+	m.current = kp
+	m.pruneExpiredLocked()
+
+	return kp, m.persist(kp)
+}
+
+// pruneExpiredLocked drops previous keys whose overlap window has elapsed.
+// Callers must hold m.mu.
+func (m *Manager) pruneExpiredLocked() {
+	now := time.Now()
+	live := m.previous[:0]
+	for _, kp := range m.previous {
+		if kp.RetireAt.After(now) {
+			live = append(live, kp)
+		}
+	}
+	m.previous = live
+}
+
+// JWK is the JSON Web Key representation of a public key. The RSA fields
+// (N, E) and EC fields (Crv, X, Y) are mutually exclusive, selected by Kty.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set, as served from /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the current and still-valid previous public keys, so
+// resource servers can verify tokens across a key rotation.
+func (m *Manager) JWKS() JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKS{}
+	if m.current != nil {
+		set.Keys = append(set.Keys, toJWK(m.current))
+	}
+	now := time.Now()
+	for _, kp := range m.previous {
+		if kp.RetireAt.After(now) {
+			set.Keys = append(set.Keys, toJWK(kp))
+		}
+	}
+	return set
+}
+
+func toJWK(kp *KeyPair) JWK {
+	switch key := kp.PrivateKey.(type) {
+	case *ecdsa.PrivateKey:
+		pub := key.PublicKey
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Use: "sig",
+			Kid: kp.KID,
+			Alg: string(kp.Alg),
+			Crv: pub.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+		}
+	case *rsa.PrivateKey:
+		pub := key.PublicKey
+		return JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Kid: kp.KID,
+			Alg: string(kp.Alg),
+			N:   base64URLEncodeBigInt(pub.N.Bytes()),
+			E:   base64URLEncodeBigInt(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	default:
+		return JWK{Kty: "unknown", Use: "sig", Kid: kp.KID, Alg: string(kp.Alg)}
+	}
+}
+
+func base64URLEncodeBigInt(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func generateKeyPair(alg Algorithm) (*KeyPair, error) {
+	var signer crypto.Signer
+	var err error
+
+	switch alg {
+	case AlgES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgRS256, "":
+		alg = AlgRS256
+		signer, err = rsa.GenerateKey(rand.Reader, keyBits)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{
+		KID:        uuid.New().String(),
+		Alg:        alg,
+		PrivateKey: signer,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+func (m *Manager) persist(kp *KeyPair) error {
+	if m.dir == "" {
+		return nil
+	}
+
+	var block *pem.Block
+	switch key := kp.PrivateKey.(type) {
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return fmt.Errorf("marshaling EC private key: %w", err)
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	default:
+		return fmt.Errorf("persisting key: unsupported key type %T", kp.PrivateKey)
+	}
+
+	path := filepath.Join(m.dir, kp.KID+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening key file: %w", err)
+	}
+	defer f.Close()
+
+	return pem.Encode(f, block)
+}
+
+// loadFromDisk populates m.current from the most recently modified key file
+// under m.dir, if any exist. Returns false if the directory was empty.
+func (m *Manager) loadFromDisk() (bool, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return false, err
+	}
+
+	var newest *KeyPair
+	var newestModTime time.Time
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return false, err
+		}
+
+		data, err := os.ReadFile(filepath.Join(m.dir, entry.Name()))
+		if err != nil {
+			return false, err
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			continue
+		}
+
+		signer, alg, err := parsePrivateKey(block)
+		if err != nil {
+			continue
+		}
+
+		kid := entry.Name()[:len(entry.Name())-len(".pem")]
+		kp := &KeyPair{KID: kid, Alg: alg, PrivateKey: signer, CreatedAt: info.ModTime()}
+
+		if newest == nil || info.ModTime().After(newestModTime) {
+			if newest != nil {
+				newest.RetireAt = newestModTime.Add(m.overlap)
+				m.previous = append(m.previous, newest)
+			}
+			newest = kp
+			newestModTime = info.ModTime()
+		} else {
+			kp.RetireAt = info.ModTime().Add(m.overlap)
+			m.previous = append(m.previous, kp)
+		}
+	}
+
+	if newest == nil {
+		return false, nil
+	}
+
+	m.current = newest
+	return true, nil
+}
+
+// parsePrivateKey decodes a PEM block persisted by persist, inferring the
+// algorithm from the key type rather than the PEM header, so keys
+// generated under one JWT_SIGNING_ALG setting still load correctly if the
+// setting is changed before a restart.
+func parsePrivateKey(block *pem.Block) (crypto.Signer, Algorithm, error) {
+	if ecKey, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return ecKey, AlgES256, nil
+	}
+	if rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return rsaKey, AlgRS256, nil
+	}
+	return nil, "", fmt.Errorf("unrecognized private key encoding")
+}
@@ -0,0 +1,11 @@
+// Package mail provides the pluggable outbound email transport used by
+// flows like password reset that need to deliver a link to the user.
+package mail
+
+import "context"
+
+// Mailer sends a single plain-text email. Implementations are swapped via
+// configuration: SMTPMailer for production, NoopMailer for local dev.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
@@ -0,0 +1,19 @@
+package mail
+
+import (
+	"context"
+	"log/slog"
+)
+
+type noopMailer struct{}
+
+// NewNoopMailer returns a Mailer that logs the message instead of sending
+// it, for local development environments without a real SMTP relay.
+func NewNoopMailer() Mailer {
+	return &noopMailer{}
+}
+
+func (m *noopMailer) Send(ctx context.Context, to, subject, body string) error {
+	slog.Info("noop mailer: email not sent", "to", to, "subject", subject, "body", body)
+	return nil
+}
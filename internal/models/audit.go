@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction identifies what kind of change an AuditLog row describes.
+type AuditAction string
+
+const (
+	AuditActionInsert    AuditAction = "INSERT"
+	AuditActionUpdate    AuditAction = "UPDATE"
+	AuditActionDelete    AuditAction = "DELETE"
+	AuditActionLogin     AuditAction = "LOGIN"
+	AuditActionRevokeAll AuditAction = "REVOKE_ALL"
+)
+
+// AuditLog records a single mutation against a user or their tokens, for
+// after-the-fact review of who changed what. ActorID is nil for
+// system-initiated changes (e.g. self-registration) that have no
+// authenticated caller.
+type AuditLog struct {
+	ID        int64           `json:"id"`
+	ActorID   *int64          `json:"actor_id"`
+	TargetID  int64           `json:"target_id"`
+	Action    AuditAction     `json:"action"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
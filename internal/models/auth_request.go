@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// AuthorizationRequest is one authorization-code-flow exchange: the
+// parameters the client passed to /authorize, plus the single-use code
+// issued once an authenticated resource owner approved it. Only CodeHash
+// is ever persisted; the raw code is handed back in the redirect exactly
+// once, mirroring DeviceAuthorization.DeviceCodeHash.
+type AuthorizationRequest struct {
+	ID                  string
+	CodeHash            string
+	ClientID            string
+	UserID              int64
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+	ConsumedAt          *time.Time
+	CreatedAt           time.Time
+}
+
+// IsExpired reports whether this request's code is past its lifetime and
+// can no longer be redeemed at /token.
+func (a *AuthorizationRequest) IsExpired() bool {
+	return time.Now().After(a.ExpiresAt)
+}
+
+// IsConsumed reports whether this request's code has already been
+// redeemed once, at /token.
+func (a *AuthorizationRequest) IsConsumed() bool {
+	return a.ConsumedAt != nil
+}
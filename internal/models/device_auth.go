@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// DeviceAuthStatus tracks where a device authorization request is in the
+// RFC 8628 device flow.
+type DeviceAuthStatus string
+
+const (
+	DeviceAuthPending  DeviceAuthStatus = "pending"
+	DeviceAuthApproved DeviceAuthStatus = "approved"
+	DeviceAuthDenied   DeviceAuthStatus = "denied"
+)
+
+// DeviceAuthorization is one device_authorization request: a device_code
+// the polling device holds, and a short user_code the user types into
+// verification_uri to approve it. Only DeviceCodeHash is ever persisted;
+// the raw device_code is returned to the client exactly once.
+type DeviceAuthorization struct {
+	ID              string           `json:"id"`
+	DeviceCodeHash  string           `json:"-"`
+	UserCode        string           `json:"user_code"`
+	ClientID        string           `json:"client_id,omitempty"`
+	Status          DeviceAuthStatus `json:"status"`
+	UserID          *int64           `json:"-"`
+	IntervalSeconds int              `json:"interval"`
+	ExpiresAt       time.Time        `json:"expires_at"`
+	LastPolledAt    *time.Time       `json:"-"`
+	CreatedAt       time.Time        `json:"created_at"`
+}
+
+// IsExpired reports whether this authorization request can no longer be
+// approved or polled because its expiry has passed.
+func (d *DeviceAuthorization) IsExpired() bool {
+	return time.Now().After(d.ExpiresAt)
+}
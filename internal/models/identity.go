@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Identity links an external OIDC provider's subject claim to a local
+// user, so the same person can sign in via multiple providers (or
+// password login) without creating duplicate accounts.
+type Identity struct {
+	ID        int64     `json:"id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
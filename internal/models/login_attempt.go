@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// LoginAttempt tracks consecutive failed logins for a single (email,
+// remote IP) pair, used to drive progressive account lockout.
+type LoginAttempt struct {
+	ID            int64
+	Email         string
+	RemoteIP      string
+	FailureCount  int
+	LockedUntil   *time.Time
+	LastFailureAt time.Time
+	UpdatedAt     time.Time
+}
+
+// IsLocked reports whether the attempt is currently within its lockout window.
+func (a *LoginAttempt) IsLocked() bool {
+	return a.LockedUntil != nil && a.LockedUntil.After(time.Now())
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// MFACredential is a user's enrolled TOTP secret and whether MFA is
+// currently enforced at login for them. Enabled only flips true once the
+// user has verified a code generated from Secret, so a half-finished
+// enrollment never locks anyone out of their own account. Secret is
+// encrypted at rest when MFAService is configured with a crypto.Encryptor;
+// otherwise it's stored as the plaintext totp.Generate produced.
+type MFACredential struct {
+	UserID int64  `json:"user_id"`
+	Secret string `json:"-"`
+
+	// LastVerifiedStep is the TOTP step number (unix time / period) that
+	// last verified successfully, so Verify can reject a replay of that
+	// same code for the rest of its 30-second validity window. Zero means
+	// no code has ever verified yet.
+	LastVerifiedStep int64     `json:"-"`
+	Enabled          bool      `json:"enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// MFAChallengeResponse is what Authenticate returns instead of a
+// TokenResponse when the user has MFA enabled: ChallengeToken must be
+// presented to /mfa/verify, along with a TOTP or recovery code, to get
+// the real access+refresh pair.
+type MFAChallengeResponse struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+// MFARecoveryCode is a single-use backup code a user can redeem instead
+// of a TOTP code, e.g. after losing their authenticator device. Only
+// CodeHash is stored; the plaintext codes are shown to the user exactly
+// once, at enrollment.
+type MFARecoveryCode struct {
+	UserID    int64      `json:"user_id"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// OAuthClient is a registered OIDC relying party: its allowed redirect
+// URIs and grant types gate /authorize and /token, the same way
+// DeviceAuthorization.ClientID identifies the caller in the device flow.
+// A public client (e.g. a PKCE-only SPA) has an empty ClientSecretHash.
+type OAuthClient struct {
+	ClientID         string    `json:"client_id"`
+	ClientSecretHash string    `json:"-"`
+	Name             string    `json:"name"`
+	RedirectURIs     []string  `json:"redirect_uris"`
+	GrantTypes       []string  `json:"grant_types"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// AllowsRedirectURI reports whether uri is on c's allow-list. Matching is
+// exact, per RFC 6749 section 3.1.2 - no wildcards, no prefix matching.
+func (c *OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range c.RedirectURIs {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsGrantType reports whether grantType is enabled for c.
+func (c *OAuthClient) AllowsGrantType(grantType string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsConfidential reports whether c is expected to authenticate itself
+// with a client secret, rather than relying on PKCE alone.
+func (c *OAuthClient) IsConfidential() bool {
+	return c.ClientSecretHash != ""
+}
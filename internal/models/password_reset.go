@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PasswordResetToken is a single-use token that authorizes one password
+// reset. Only TokenHash (its SHA-256 hash) is ever persisted; the raw
+// token is sent to the user once, via the reset link, and never stored.
+type PasswordResetToken struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsExpired reports whether the token's expiry has passed.
+func (t *PasswordResetToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsUsed reports whether the token has already been redeemed.
+func (t *PasswordResetToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
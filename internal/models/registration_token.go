@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// RegistrationToken is an admin-issued invite that lets signup bypass
+// whatever public-registration policy is in force. Only the SHA-256 hash
+// of the raw token is ever stored, matching PasswordResetToken.
+type RegistrationToken struct {
+	ID            string    `json:"id"`
+	TokenHash     string    `json:"-"`
+	UsesAllowed   int       `json:"uses_allowed"`
+	UsesCompleted int       `json:"uses_completed"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	PendingRole   string    `json:"pending_role,omitempty"`
+	CreatedBy     int64     `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// IsExpired reports whether t can no longer be redeemed because its
+// expiry has passed.
+func (t *RegistrationToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsExhausted reports whether every allowed use of t has already been
+// consumed.
+func (t *RegistrationToken) IsExhausted() bool {
+	return t.UsesCompleted >= t.UsesAllowed
+}
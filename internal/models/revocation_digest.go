@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RevocationDigest is a signed, versioned Bloom filter over currently
+// revoked, non-expired token IDs, served from
+// GET /.well-known/revocation-digest so resource servers can check
+// revocation locally instead of round-tripping to IsTokenRevoked on every
+// request, falling back to it only on a (possibly false-positive) hit.
+type RevocationDigest struct {
+	Version   int64     `json:"version"`
+	Bits      []byte    `json:"bits"`
+	NumBits   uint64    `json:"num_bits"`
+	NumHashes uint64    `json:"num_hashes"`
+	BuiltAt   time.Time `json:"built_at"`
+	KeyID     string    `json:"kid"`
+	Signature []byte    `json:"signature"`
+}
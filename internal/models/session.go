@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Session represents an active login for a user on a specific device. It
+// is recorded on every successful Authenticate so TokenPreferences.MaxActiveSessions
+// can be enforced and so users can audit or revoke their own logins.
+type Session struct {
+	ID             string    `json:"id"`
+	UserID         int64     `json:"user_id"`
+	DeviceID       string    `json:"device_id"`
+	RefreshTokenID string    `json:"-"`
+	UserAgent      string    `json:"user_agent,omitempty"`
+	IPAddress      string    `json:"ip_address,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastSeenAt     time.Time `json:"last_seen_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
@@ -21,6 +21,27 @@ type Claims struct {
 	// Extra metadata
 	DeviceID  string   `json:"device_id,omitempty"`  // For tracking different devices
 	ClientID  string   `json:"client_id,omitempty"`  // For different client applications
+
+	// Standard OIDC claims
+	Nonce  string `json:"nonce,omitempty"`   // Echoes the nonce supplied in the authorization request
+	AtHash string `json:"at_hash,omitempty"` // Access token hash, set on ID tokens
+
+	// AuthTime is when the end user actually entered their credentials,
+	// carried forward unchanged across RefreshAccessToken/RotateRefreshToken
+	// so it keeps describing the original login; Reauthenticate is the only
+	// thing that resets it, by design - that's what lets a step-up check
+	// tell a merely-refreshed token from one backed by a fresh password entry.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+
+	// AMR lists the authentication methods the holder actually completed
+	// ("pwd", "otp"). An mfa_challenge token carries an empty AMR - it
+	// proves nothing on its own, only that the password step passed.
+	AMR []string `json:"amr,omitempty"`
+
+	// AAL is the OIDC/NIST 800-63 Authenticator Assurance Level reached:
+	// 1 for password-only, 2 once a second factor (otp) has also been
+	// verified. Middleware guarding sensitive routes can require AAL 2.
+	AAL int `json:"aal,omitempty"`
 }
 
 // TokenResponse represents the API response containing both access and refresh tokens
@@ -53,6 +74,22 @@ type TokenMetadata struct {
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiresAt  time.Time `json:"expires_at"`
 	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+
+	// ParentTokenID is the jti of the refresh token this one was rotated
+	// from, empty for a token issued directly by Authenticate.
+	ParentTokenID string `json:"parent_token_id,omitempty"`
+
+	// FamilyID groups a refresh token with every token descended from it
+	// through rotation, back to the one Authenticate originally issued
+	// (whose own FamilyID is its own jti). Reuse detection revokes the
+	// whole family rather than every token the user has, so rotating a
+	// stolen token on one device doesn't log the user out of others.
+	FamilyID string `json:"family_id,omitempty"`
+
+	// RotatedAt is set the moment this refresh token is exchanged for a
+	// new pair; a second exchange attempt after this is set means the
+	// token was stolen and reused.
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
 }
 
 // RefreshTokenRequest represents the request body for token refresh
@@ -84,12 +121,70 @@ type TokenPreferences struct {
 	MaxActiveSessions      int    `json:"max_active_sessions"`
 }
 
+// IntrospectionResponse is the RFC 7662 response shape returned by the
+// resource-server-facing introspection endpoint. Active is the only field
+// guaranteed to be set for an inactive token - the rest are omitted so a
+// caller can't distinguish "expired" from "revoked" from "never existed"
+// by which fields are present.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Sub       string `json:"sub,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Nbf       int64  `json:"nbf,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+
+	// Role, SessionID, and FamilyID are non-standard additions resource
+	// servers may use for authorization decisions beyond what RFC 7662
+	// defines.
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	FamilyID  string `json:"family_id,omitempty"`
+}
+
+// TokenFilter narrows ListTokens/StreamTokens to a subset of
+// token_metadata for admin tooling. A zero-valued field is ignored; the
+// *bool for IsRevoked distinguishes "not set" from "filter on false".
+type TokenFilter struct {
+	UserID        string
+	TokenType     string
+	DeviceID      string
+	ClientID      string
+	IsRevoked     *bool
+	CreatedBefore time.Time
+	CreatedAfter  time.Time
+	ExpiresBefore time.Time
+	ExpiresAfter  time.Time
+}
+
 // Constants for token types and defaults
 const (
 	TokenTypeAccess  = "access"
 	TokenTypeRefresh = "refresh"
-	
+
+	// TokenTypeMFAChallenge marks a short-lived, single-purpose token
+	// issued in place of a normal access/refresh pair when GenerateTokens
+	// finds MFA enabled for the user. It's only good at /mfa/verify - it
+	// carries no roles or session rights of its own.
+	TokenTypeMFAChallenge = "mfa_challenge"
+
+	// TokenTypeIDToken marks an OIDC ID token minted by authserver.Service
+	// alongside an access/refresh pair. Unlike access and refresh tokens it
+	// is never looked up in the TokenStore - it's a one-way assertion about
+	// the authentication event, not a credential the holder presents back.
+	TokenTypeIDToken = "id_token"
+
 	DefaultAccessTokenLifetime  = 15 * time.Minute
 	DefaultRefreshTokenLifetime = 7 * 24 * time.Hour // 7 days
 	DefaultTokenType            = "Bearer"
+
+	// DefaultMFAChallengeLifetime is how long an mfa_challenge token stays
+	// redeemable before the user has to log in again from scratch.
+	DefaultMFAChallengeLifetime = 5 * time.Minute
+
+	// DeviceCodeGrantType is the grant_type value that selects the RFC
+	// 8628 device authorization flow on the token polling endpoint.
+	DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
 )
@@ -1,15 +1,21 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 // User represents a user entity in the system.
 type User struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"first_name,omitempty"`
-	LastName  string    `json:"last_name,omitempty"`
-	Password  string    `json:"-"`
-	Active    *int       `json:"active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int64        `json:"id"`
+	Email     string       `json:"email"`
+	FirstName string       `json:"first_name,omitempty"`
+	LastName  string       `json:"last_name,omitempty"`
+	Password  string       `json:"-"`
+	Role      string       `json:"role"`
+	IsActive  bool         `json:"is_active"`
+	LastLogin time.Time    `json:"last_login,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	DeletedAt sql.NullTime `json:"-"`
 }
@@ -0,0 +1,37 @@
+package oidcauth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eupneart/auth-service/pkg/env"
+)
+
+// Manager holds one discovered Provider per entry in env.EnvConfig.OIDCProviders.
+type Manager struct {
+	providers map[string]*Provider
+}
+
+// NewManager discovers every provider in configs up front, so a
+// misconfigured issuer fails fast at startup rather than on first login.
+func NewManager(ctx context.Context, configs map[string]env.ProviderConfig) (*Manager, error) {
+	providers := make(map[string]*Provider, len(configs))
+	for name, cfg := range configs {
+		provider, err := newProvider(ctx, name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers[name] = provider
+	}
+	return &Manager{providers: providers}, nil
+}
+
+// Get looks up a provider by name, as used to dispatch
+// /auth/oidc/{provider}/... requests.
+func (m *Manager) Get(name string) (*Provider, error) {
+	provider, ok := m.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown oidc provider %q", name)
+	}
+	return provider, nil
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import "context"
+
+type actorCtxKey struct{}
+
+// actor carries the identity of whoever triggered a mutation, for
+// AuditingUserRepo and AuditingTokenStore to record on the resulting
+// audit row. It travels on ctx, the same way request-scoped claims and
+// the logger already do, so callers don't need new parameters threaded
+// through every UserRepoInterface/TokenStore method.
+type actor struct {
+	id *int64
+	ip string
+}
+
+// WithActor annotates ctx with the caller responsible for the mutations
+// performed with it. actorID is nil for system-initiated changes (e.g.
+// self-registration) that have no authenticated caller.
+func WithActor(ctx context.Context, actorID *int64, remoteIP string) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor{id: actorID, ip: remoteIP})
+}
+
+// actorFromContext returns the actor stashed by WithActor, or a zero
+// actor (nil ID, empty IP) if none was set.
+func actorFromContext(ctx context.Context) actor {
+	a, _ := ctx.Value(actorCtxKey{}).(actor)
+	return a
+}
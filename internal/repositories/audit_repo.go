@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+// AuditRepo writes to user_audit_log (actor_id, target_id, action,
+// before, after, ip, created_at), a table assumed to already exist in
+// the target schema - there's no migrations directory in this repo yet,
+// the same situation token_metadata's other columns are already in.
+type AuditRepo struct {
+	DB *sql.DB
+}
+
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{DB: db}
+}
+
+// Create inserts log via exec, so callers that already hold a *sql.Tx for
+// the mutation being audited can pass it in directly.
+func (r *AuditRepo) Create(ctx context.Context, exec SQLExecutor, log *models.AuditLog) error {
+	stmt := `INSERT INTO user_audit_log (actor_id, target_id, action, before, after, ip, created_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`
+
+	err := exec.QueryRowContext(ctx, stmt,
+		log.ActorID,
+		log.TargetID,
+		log.Action,
+		log.Before,
+		log.After,
+		log.IP,
+		log.CreatedAt,
+	).Scan(&log.ID, &log.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting audit log row: %w", err)
+	}
+
+	return nil
+}
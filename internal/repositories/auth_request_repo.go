@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+const authRequestColumns = `
+  id, code_hash, client_id, user_id, redirect_uri, scope, state, nonce,
+  code_challenge, code_challenge_method, expires_at, consumed_at, created_at
+`
+
+type AuthRequestRepo struct {
+	DB *sql.DB
+}
+
+func NewAuthRequestRepo(db *sql.DB) AuthRequestRepository {
+	return &AuthRequestRepo{DB: db}
+}
+
+// Create inserts a new authorization request row. The caller is expected
+// to have already generated req.ID and hashed the raw code into
+// req.CodeHash.
+func (r *AuthRequestRepo) Create(ctx context.Context, req *models.AuthorizationRequest) error {
+	stmt := `INSERT INTO oauth_authorization_requests
+	           (id, code_hash, client_id, user_id, redirect_uri, scope, state, nonce, code_challenge, code_challenge_method, expires_at, consumed_at, created_at)
+	         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err := r.DB.ExecContext(ctx, stmt,
+		req.ID,
+		req.CodeHash,
+		req.ClientID,
+		req.UserID,
+		req.RedirectURI,
+		req.Scope,
+		req.State,
+		req.Nonce,
+		req.CodeChallenge,
+		req.CodeChallengeMethod,
+		req.ExpiresAt,
+		nullableTime(req.ConsumedAt),
+		req.CreatedAt,
+	)
+	if err != nil {
+		slog.Error("failed to create authorization request",
+			"error", err,
+			"query", stmt,
+			"auth_request_id", req.ID,
+			"method", "AuthRequestRepo.Create")
+		return fmt.Errorf("creating authorization request: %w", err)
+	}
+
+	return nil
+}
+
+// GetByCodeHash retrieves an authorization request by the SHA-256 hash of
+// its raw code, for the /token authorization_code grant.
+func (r *AuthRequestRepo) GetByCodeHash(ctx context.Context, codeHash string) (*models.AuthorizationRequest, error) {
+	query := fmt.Sprintf(`SELECT %s FROM oauth_authorization_requests WHERE code_hash = $1`, authRequestColumns)
+
+	req, err := scanAuthRequestRow(r.DB.QueryRowContext(ctx, query, codeHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("authorization request not found")
+		}
+		slog.Error("failed to query authorization request by code hash",
+			"error", err,
+			"query", query,
+			"method", "AuthRequestRepo.GetByCodeHash")
+		return nil, fmt.Errorf("querying authorization request: %w", err)
+	}
+
+	return req, nil
+}
+
+// Consume marks id's code used, failing if it was already consumed so a
+// code can never be redeemed twice even under a concurrent /token race.
+func (r *AuthRequestRepo) Consume(ctx context.Context, id string) error {
+	stmt := `UPDATE oauth_authorization_requests SET consumed_at = $1 WHERE id = $2 AND consumed_at IS NULL`
+
+	result, err := r.DB.ExecContext(ctx, stmt, time.Now(), id)
+	if err != nil {
+		slog.Error("failed to consume authorization request",
+			"error", err,
+			"query", stmt,
+			"auth_request_id", id,
+			"method", "AuthRequestRepo.Consume")
+		return fmt.Errorf("consuming authorization request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking consume result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("authorization code already used or not found")
+	}
+
+	return nil
+}
+
+type authRequestRow interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAuthRequestRow(row authRequestRow) (*models.AuthorizationRequest, error) {
+	var req models.AuthorizationRequest
+	var consumedAt sql.NullTime
+
+	err := row.Scan(
+		&req.ID,
+		&req.CodeHash,
+		&req.ClientID,
+		&req.UserID,
+		&req.RedirectURI,
+		&req.Scope,
+		&req.State,
+		&req.Nonce,
+		&req.CodeChallenge,
+		&req.CodeChallengeMethod,
+		&req.ExpiresAt,
+		&consumedAt,
+		&req.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if consumedAt.Valid {
+		req.ConsumedAt = &consumedAt.Time
+	}
+
+	return &req, nil
+}
@@ -0,0 +1,279 @@
+// Package boltstore implements repositories.TokenStore against a local
+// BoltDB file, for single-node deployments that want token metadata to
+// survive a restart without standing up Postgres or Redis just for that.
+package boltstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+	bolt "go.etcd.io/bbolt"
+)
+
+var tokensBucket = []byte("tokens")
+
+// BoltTokenStore implements repositories.TokenStore against a single BoltDB
+// file. Unlike RedisTokenStore it keeps no secondary indexes: lookups by
+// user or family do a full bucket scan, which is fine at the token volumes
+// a single embedded store is expected to hold.
+type BoltTokenStore struct {
+	DB *bolt.DB
+}
+
+// NewBoltTokenStore opens (creating if necessary) a BoltDB file at path and
+// returns a TokenStore backed by it.
+func NewBoltTokenStore(path string) (repositories.TokenStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening bolt database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(tokensBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating tokens bucket: %w", err)
+	}
+
+	return &BoltTokenStore{DB: db}, nil
+}
+
+func (s *BoltTokenStore) SaveTokenMetadata(ctx context.Context, metadata *models.TokenMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		slog.Error("failed to marshal token metadata",
+			"error", err,
+			"token_id", metadata.ID,
+			"method", "BoltTokenStore.SaveTokenMetadata")
+		return fmt.Errorf("marshaling token metadata: %w", err)
+	}
+
+	err = s.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tokensBucket).Put([]byte(metadata.ID), data)
+	})
+	if err != nil {
+		slog.Error("failed to save token metadata",
+			"error", err,
+			"token_id", metadata.ID,
+			"user_id", metadata.UserID,
+			"method", "BoltTokenStore.SaveTokenMetadata")
+		return fmt.Errorf("saving token metadata: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BoltTokenStore) GetTokenMetadata(ctx context.Context, tokenID string) (*models.TokenMetadata, error) {
+	var metadata models.TokenMetadata
+	found := false
+
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(tokensBucket).Get([]byte(tokenID))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &metadata)
+	})
+	if err != nil {
+		slog.Error("failed to query token metadata",
+			"error", err,
+			"token_id", tokenID,
+			"method", "BoltTokenStore.GetTokenMetadata")
+		return nil, fmt.Errorf("querying token metadata: %w", err)
+	}
+	if !found {
+		slog.Warn("token metadata not found",
+			"token_id", tokenID,
+			"method", "BoltTokenStore.GetTokenMetadata")
+		return nil, fmt.Errorf("token not found")
+	}
+
+	return &metadata, nil
+}
+
+func (s *BoltTokenStore) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	metadata, err := s.GetTokenMetadata(ctx, tokenID)
+	if err != nil {
+		// Missing/unreadable token is treated as revoked, matching TokenRepo.
+		return true, nil
+	}
+	return metadata.IsRevoked, nil
+}
+
+func (s *BoltTokenStore) RevokeToken(ctx context.Context, tokenID string) error {
+	metadata, err := s.GetTokenMetadata(ctx, tokenID)
+	if err != nil {
+		return fmt.Errorf("token not found")
+	}
+
+	metadata.IsRevoked = true
+	return s.SaveTokenMetadata(ctx, metadata)
+}
+
+func (s *BoltTokenStore) RevokeTokenByID(ctx context.Context, tokenID string) error {
+	return s.RevokeToken(ctx, tokenID)
+}
+
+// RevokeAllTokensForUser scans every token, revoking the ones owned by
+// userID. See the BoltTokenStore doc comment on why this isn't indexed.
+func (s *BoltTokenStore) RevokeAllTokensForUser(ctx context.Context, userID string) error {
+	revoked, err := s.revokeMatching(func(m *models.TokenMetadata) bool {
+		return m.UserID == userID
+	})
+	if err != nil {
+		slog.Error("failed to revoke all tokens for user",
+			"error", err,
+			"user_id", userID,
+			"method", "BoltTokenStore.RevokeAllTokensForUser")
+		return err
+	}
+
+	slog.Info("successfully revoked all tokens for user",
+		"user_id", userID,
+		"tokens_revoked", revoked)
+
+	return nil
+}
+
+// RevokeTokenFamily scans every token, revoking the ones descended from the
+// same original issuance as familyID.
+func (s *BoltTokenStore) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	revoked, err := s.revokeMatching(func(m *models.TokenMetadata) bool {
+		return m.FamilyID == familyID
+	})
+	if err != nil {
+		slog.Error("failed to revoke token family",
+			"error", err,
+			"family_id", familyID,
+			"method", "BoltTokenStore.RevokeTokenFamily")
+		return err
+	}
+
+	slog.Info("successfully revoked token family",
+		"family_id", familyID,
+		"tokens_revoked", revoked)
+
+	return nil
+}
+
+// revokeMatching sets IsRevoked on every token for which match returns
+// true, returning how many were changed.
+func (s *BoltTokenStore) revokeMatching(match func(*models.TokenMetadata) bool) (int, error) {
+	revoked := 0
+
+	err := s.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(tokensBucket)
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var metadata models.TokenMetadata
+			if err := json.Unmarshal(v, &metadata); err != nil {
+				return err
+			}
+			if !match(&metadata) || metadata.IsRevoked {
+				return nil
+			}
+
+			metadata.IsRevoked = true
+			data, err := json.Marshal(&metadata)
+			if err != nil {
+				return err
+			}
+			revoked++
+			return bucket.Put(k, data)
+		})
+	})
+
+	return revoked, err
+}
+
+func (s *BoltTokenStore) UpdateLastUsed(ctx context.Context, tokenID string) error {
+	metadata, err := s.GetTokenMetadata(ctx, tokenID)
+	if err != nil {
+		// Don't fail validation over a token that's already gone.
+		return nil
+	}
+
+	metadata.LastUsedAt = time.Now()
+	return s.SaveTokenMetadata(ctx, metadata)
+}
+
+// CleanupExpiredTokens removes every token whose ExpiresAt has passed.
+func (s *BoltTokenStore) CleanupExpiredTokens(ctx context.Context) error {
+	now := time.Now()
+	removed := 0
+
+	err := s.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(tokensBucket)
+
+		var expiredKeys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var metadata models.TokenMetadata
+			if err := json.Unmarshal(v, &metadata); err != nil {
+				return err
+			}
+			if metadata.ExpiresAt.Before(now) {
+				expiredKeys = append(expiredKeys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expiredKeys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("failed to clean up expired tokens",
+			"error", err,
+			"method", "BoltTokenStore.CleanupExpiredTokens")
+		return fmt.Errorf("cleaning up expired tokens: %w", err)
+	}
+
+	slog.Debug("cleaned up expired tokens", "tokens_removed", removed)
+	return nil
+}
+
+func (s *BoltTokenStore) GetAllTokensForUser(ctx context.Context, userID string) ([]models.TokenMetadata, error) {
+	var tokens []models.TokenMetadata
+
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(tokensBucket).ForEach(func(k, v []byte) error {
+			var metadata models.TokenMetadata
+			if err := json.Unmarshal(v, &metadata); err != nil {
+				return err
+			}
+			if metadata.UserID == userID {
+				tokens = append(tokens, metadata)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		slog.Error("failed to list tokens for user",
+			"error", err,
+			"user_id", userID,
+			"method", "BoltTokenStore.GetAllTokensForUser")
+		return nil, fmt.Errorf("listing tokens for user: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Close releases the underlying BoltDB file handle.
+func (s *BoltTokenStore) Close() error {
+	return s.DB.Close()
+}
@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+const deviceAuthColumns = `
+  id, device_code_hash, user_code, client_id, status,
+  user_id, interval_seconds, expires_at, last_polled_at, created_at
+`
+
+type DeviceAuthRepo struct {
+	DB *sql.DB
+}
+
+func NewDeviceAuthRepo(db *sql.DB) DeviceAuthRepository {
+	return &DeviceAuthRepo{DB: db}
+}
+
+// Create inserts a new device authorization row. The caller is expected
+// to have already generated auth.ID and hashed the raw device_code into
+// DeviceCodeHash.
+func (r *DeviceAuthRepo) Create(ctx context.Context, auth *models.DeviceAuthorization) error {
+	stmt := `INSERT INTO device_authorizations (id, device_code_hash, user_code, client_id, status, user_id, interval_seconds, expires_at, last_polled_at, created_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.DB.ExecContext(ctx, stmt,
+		auth.ID,
+		auth.DeviceCodeHash,
+		auth.UserCode,
+		nullableString(auth.ClientID),
+		string(auth.Status),
+		nullableInt64(auth.UserID),
+		auth.IntervalSeconds,
+		auth.ExpiresAt,
+		nullableTime(auth.LastPolledAt),
+		auth.CreatedAt,
+	)
+	if err != nil {
+		slog.Error("failed to create device authorization",
+			"error", err,
+			"query", stmt,
+			"device_auth_id", auth.ID,
+			"method", "DeviceAuthRepo.Create")
+		return fmt.Errorf("creating device authorization: %w", err)
+	}
+
+	slog.Info("successfully created device authorization",
+		"device_auth_id", auth.ID,
+		"client_id", auth.ClientID)
+
+	return nil
+}
+
+// GetByUserCode retrieves a device authorization by the code the user
+// types into verification_uri, for the /oauth/device/verify handler.
+func (r *DeviceAuthRepo) GetByUserCode(ctx context.Context, userCode string) (*models.DeviceAuthorization, error) {
+	query := fmt.Sprintf(`SELECT %s FROM device_authorizations WHERE user_code = $1`, deviceAuthColumns)
+
+	auth, err := scanDeviceAuthRow(r.DB.QueryRowContext(ctx, query, userCode))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("device authorization not found")
+		}
+		slog.Error("failed to query device authorization by user code",
+			"error", err,
+			"query", query,
+			"method", "DeviceAuthRepo.GetByUserCode")
+		return nil, fmt.Errorf("querying device authorization: %w", err)
+	}
+
+	return auth, nil
+}
+
+// Approve marks the request matching userCode as approved by userID.
+func (r *DeviceAuthRepo) Approve(ctx context.Context, userCode string, userID int64) error {
+	stmt := `UPDATE device_authorizations SET status = $1, user_id = $2 WHERE user_code = $3 AND status = $4 AND expires_at > $5`
+
+	result, err := r.DB.ExecContext(ctx, stmt, string(models.DeviceAuthApproved), userID, userCode, string(models.DeviceAuthPending), time.Now())
+	if err != nil {
+		slog.Error("failed to approve device authorization",
+			"error", err,
+			"query", stmt,
+			"method", "DeviceAuthRepo.Approve")
+		return fmt.Errorf("approving device authorization: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking approve result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("device authorization not found, expired, or already resolved")
+	}
+
+	return nil
+}
+
+// Deny marks the request matching userCode as denied, e.g. when the user
+// rejects the sign-in prompt.
+func (r *DeviceAuthRepo) Deny(ctx context.Context, userCode string) error {
+	stmt := `UPDATE device_authorizations SET status = $1 WHERE user_code = $2 AND status = $3`
+
+	result, err := r.DB.ExecContext(ctx, stmt, string(models.DeviceAuthDenied), userCode, string(models.DeviceAuthPending))
+	if err != nil {
+		slog.Error("failed to deny device authorization",
+			"error", err,
+			"query", stmt,
+			"method", "DeviceAuthRepo.Deny")
+		return fmt.Errorf("denying device authorization: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking deny result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("device authorization not found or already resolved")
+	}
+
+	return nil
+}
+
+// Poll looks up the request matching deviceCodeHash inside a transaction,
+// enforcing IntervalSeconds server-side: a poll that arrives sooner than
+// the last one plus the interval is rejected with tooSoon=true and doesn't
+// advance LastPolledAt, so a well-behaved client's next on-schedule poll
+// isn't also rejected.
+func (r *DeviceAuthRepo) Poll(ctx context.Context, deviceCodeHash string) (*models.DeviceAuthorization, bool, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("beginning device authorization poll: %w", err)
+	}
+	defer tx.Rollback()
+
+	lockQuery := fmt.Sprintf(`SELECT %s FROM device_authorizations WHERE device_code_hash = $1 FOR UPDATE`, deviceAuthColumns)
+	auth, err := scanDeviceAuthRow(tx.QueryRowContext(ctx, lockQuery, deviceCodeHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, fmt.Errorf("device authorization not found")
+		}
+		slog.Error("failed to lock device authorization for poll",
+			"error", err,
+			"method", "DeviceAuthRepo.Poll")
+		return nil, false, fmt.Errorf("querying device authorization: %w", err)
+	}
+
+	now := time.Now()
+	if auth.LastPolledAt != nil && now.Before(auth.LastPolledAt.Add(time.Duration(auth.IntervalSeconds)*time.Second)) {
+		return auth, true, nil
+	}
+
+	updateStmt := `UPDATE device_authorizations SET last_polled_at = $1 WHERE id = $2`
+	if _, err := tx.ExecContext(ctx, updateStmt, now, auth.ID); err != nil {
+		slog.Error("failed to update device authorization poll time",
+			"error", err,
+			"device_auth_id", auth.ID,
+			"method", "DeviceAuthRepo.Poll")
+		return nil, false, fmt.Errorf("recording device authorization poll: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("committing device authorization poll: %w", err)
+	}
+
+	auth.LastPolledAt = &now
+
+	return auth, false, nil
+}
+
+// ========================= Helper functions ============================
+
+type deviceAuthRow interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeviceAuthRow(row deviceAuthRow) (*models.DeviceAuthorization, error) {
+	var auth models.DeviceAuthorization
+	var clientID sql.NullString
+	var status string
+	var userID sql.NullInt64
+	var lastPolledAt sql.NullTime
+
+	err := row.Scan(
+		&auth.ID,
+		&auth.DeviceCodeHash,
+		&auth.UserCode,
+		&clientID,
+		&status,
+		&userID,
+		&auth.IntervalSeconds,
+		&auth.ExpiresAt,
+		&lastPolledAt,
+		&auth.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	auth.Status = models.DeviceAuthStatus(status)
+	if clientID.Valid {
+		auth.ClientID = clientID.String
+	}
+	if userID.Valid {
+		auth.UserID = &userID.Int64
+	}
+	if lastPolledAt.Valid {
+		auth.LastPolledAt = &lastPolledAt.Time
+	}
+
+	return &auth, nil
+}
+
+// nullableInt64 converts an optional int64 into the sql.NullInt64 a
+// nullable column expects.
+func nullableInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
+
+// nullableTime converts an optional time.Time into the sql.NullTime a
+// nullable column expects.
+func nullableTime(v *time.Time) sql.NullTime {
+	if v == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *v, Valid: true}
+}
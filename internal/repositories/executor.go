@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SQLExecutor is satisfied by both *sql.DB and *sql.Tx. UserRepo and
+// AuditRepo are written against it instead of *sql.DB directly so a
+// decorator (AuditingUserRepo, AuditingTokenStore) can run a mutation and
+// its audit row on the same *sql.Tx without duplicating any SQL.
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
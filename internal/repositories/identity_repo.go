@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+type IdentityRepo struct {
+	DB *sql.DB
+}
+
+func NewIdentityRepo(db *sql.DB) IdentityRepository {
+	return &IdentityRepo{DB: db}
+}
+
+// Create links a (provider, subject) pair to a local user.
+func (r *IdentityRepo) Create(ctx context.Context, identity *models.Identity) error {
+	stmt := `INSERT INTO identities (provider, subject, user_id, created_at)
+             VALUES ($1, $2, $3, $4) RETURNING id`
+
+	err := r.DB.QueryRowContext(ctx, stmt,
+		identity.Provider,
+		identity.Subject,
+		identity.UserID,
+		identity.CreatedAt,
+	).Scan(&identity.ID)
+	if err != nil {
+		slog.Error("failed to create identity",
+			"error", err,
+			"query", stmt,
+			"provider", identity.Provider,
+			"user_id", identity.UserID,
+			"method", "IdentityRepo.Create")
+		return fmt.Errorf("creating identity: %w", err)
+	}
+
+	return nil
+}
+
+// GetByProviderSubject looks up the user already linked to an external
+// identity, or returns an error if no such link exists yet.
+func (r *IdentityRepo) GetByProviderSubject(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	query := `SELECT id, provider, subject, user_id, created_at FROM identities WHERE provider = $1 AND subject = $2`
+
+	var identity models.Identity
+	err := r.DB.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.UserID,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("identity not found")
+		}
+		slog.Error("failed to query identity",
+			"error", err,
+			"query", query,
+			"provider", provider,
+			"method", "IdentityRepo.GetByProviderSubject")
+		return nil, fmt.Errorf("querying identity: %w", err)
+	}
+
+	return &identity, nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/eupneart/auth-service/internal/models"
 )
@@ -10,11 +11,35 @@ type UserRepoInterface interface {
 	GetAll(ctx context.Context) ([]*models.User, error)
 	GetByID(ctx context.Context, id int64) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+
+	// Update writes every field of u, including IsActive - it's meant for
+	// a fully-populated User (as read back from GetByID/GetByEmail, or
+	// built from a caller-supplied full-object request), not a partial
+	// struct built around whatever field is actually changing. A
+	// narrower change, e.g. just the password, must go through a
+	// dedicated method like UpdatePassword instead.
 	Update(ctx context.Context, u models.User) error
+
+	// UpdatePassword sets userID's password hash and only that column
+	// (plus updated_at). Unlike Update, it can't be misused to silently
+	// reset unrelated columns like IsActive to their zero value, so
+	// every password-only change - a reset or an opportunistic rehash -
+	// must go through this instead of building a partial User for Update.
+	UpdatePassword(ctx context.Context, userID int64, passwordHash string) error
+
 	DeleteByID(ctx context.Context, id int64) error
   Insert(ctx context.Context, u models.User) (int64, error)
 }
 
+// AuditRepository records one row per mutation against a user or their
+// tokens. It's written against SQLExecutor (not *sql.DB) so AuditingUserRepo
+// can call Create on the same *sql.Tx as the mutation it describes,
+// making the pair atomic; AuditingTokenStore uses it more loosely, since
+// TokenStore has backends that don't speak sql.Tx at all.
+type AuditRepository interface {
+	Create(ctx context.Context, exec SQLExecutor, log *models.AuditLog) error
+}
+
 type TokenStore interface {
 	SaveTokenMetadata(ctx context.Context, metadata *models.TokenMetadata) error
 	GetTokenMetadata(ctx context.Context, tokenID string) (*models.TokenMetadata, error)
@@ -22,7 +47,208 @@ type TokenStore interface {
 	RevokeToken(ctx context.Context, tokenID string) error
 	RevokeTokenByID(ctx context.Context, tokenID string) error
 	RevokeAllTokensForUser(ctx context.Context, userID string) error
+
+	// RevokeTokenFamily revokes every refresh token descended from the same
+	// original issuance as familyID (see models.TokenMetadata.FamilyID),
+	// without touching the user's other token families. Used when refresh
+	// token reuse is detected, so the compromise is contained to the
+	// device/session it happened on.
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+
 	UpdateLastUsed(ctx context.Context, tokenID string) error
 	CleanupExpiredTokens(ctx context.Context) error
 	GetAllTokensForUser(ctx context.Context, userID string) ([]models.TokenMetadata, error)
 }
+
+// TokenAdminRepository provides keyset-paginated and streaming reads over
+// token_metadata for admin tooling (dashboards, exports). It's kept
+// separate from TokenStore, the same way DeviceAuthRepository is, so the
+// redis and in-memory TokenStore backends aren't forced to implement
+// dashboard-grade filtering they have no efficient way to do; it's
+// Postgres-only for now.
+type TokenAdminRepository interface {
+	// ListTokens returns up to limit tokens matching filter, newest
+	// created_at first, plus the opaque cursor to pass back for the next
+	// page ("" once exhausted).
+	ListTokens(ctx context.Context, filter models.TokenFilter, cursor string, limit int) (tokens []models.TokenMetadata, nextCursor string, err error)
+
+	// StreamTokens sends every token matching filter on the returned
+	// channel in created_at order, closing it when done or when ctx is
+	// canceled. Meant for admin exports where loading the full result set
+	// into memory first isn't practical.
+	StreamTokens(ctx context.Context, filter models.TokenFilter) (<-chan models.TokenMetadata, error)
+}
+
+// RevocationRepository feeds RevocationDigestService the token IDs it
+// needs to maintain its signed Bloom filter. Postgres-only, the same
+// scoping as TokenAdminRepository and DeviceAuthRepository.
+type RevocationRepository interface {
+	// RevokedTokenIDsSince returns the IDs of tokens revoked (and not yet
+	// expired) with revoked_at_seq greater than sinceSeq, in ascending
+	// seq order, plus the highest seq returned (sinceSeq unchanged if no
+	// rows matched) - so the caller can fold them into its Bloom filter
+	// incrementally instead of rescanning every revoked token each time.
+	RevokedTokenIDsSince(ctx context.Context, sinceSeq int64) (ids []string, maxSeq int64, err error)
+}
+
+// SessionRepository persists the active-session records used to enforce
+// TokenPreferences.MaxActiveSessions and to back the /sessions endpoints.
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	GetAllForUser(ctx context.Context, userID int64) ([]models.Session, error)
+	CountForUser(ctx context.Context, userID int64) (int64, error)
+	GetOldestForUser(ctx context.Context, userID int64) (*models.Session, error)
+	DeleteByID(ctx context.Context, id string) error
+	DeleteAllForUser(ctx context.Context, userID int64) error
+	DeleteAllForUserExcept(ctx context.Context, userID int64, exceptDeviceID string) error
+
+	// UpdateLastSeen persists the last-seen timestamp for a session. The
+	// in-memory services/sessions.Store calls this on a flush interval
+	// rather than on every request, so it's the only write path that needs
+	// to tolerate being slightly stale.
+	UpdateLastSeen(ctx context.Context, id string, lastSeenAt time.Time) error
+}
+
+// SessionNotifier lets a TokenStore push live-session cache invalidation
+// into services/sessions.Store without depending on it directly, so
+// RevokeAllTokensForUser and UpdateLastUsed don't wait for the session
+// reaper's next sweep to reflect a revocation.
+type SessionNotifier interface {
+	EvictUser(userID int64)
+	TouchRefreshToken(refreshTokenID string)
+}
+
+// PasswordResetRepository persists the single-use tokens that back the
+// forgot-password flow. Only a token's SHA-256 hash is ever stored.
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id int64) error
+	DeleteExpired(ctx context.Context) error
+}
+
+// IdentityRepository persists the (provider, subject) -> user_id links
+// created by OIDC social login.
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *models.Identity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*models.Identity, error)
+}
+
+// LoginAttemptRepository persists per (email, remote IP) failed-login
+// counters, used to drive progressive account lockout on Authenticate.
+type LoginAttemptRepository interface {
+	GetByEmailIP(ctx context.Context, email, remoteIP string) (*models.LoginAttempt, error)
+	Upsert(ctx context.Context, attempt *models.LoginAttempt) error
+	Reset(ctx context.Context, email, remoteIP string) error
+}
+
+// MFARepository persists each user's TOTP enrollment and their single-use
+// recovery codes. Only a hash of each recovery code is ever stored.
+type MFARepository interface {
+	// GetCredential returns userID's enrolled credential, or nil if they
+	// haven't started enrollment.
+	GetCredential(ctx context.Context, userID int64) (*models.MFACredential, error)
+
+	// SaveCredential inserts or replaces userID's credential (enrolling
+	// fresh, or re-enrolling after a reset).
+	SaveCredential(ctx context.Context, cred *models.MFACredential) error
+
+	// SetEnabled flips whether MFA is enforced at login for userID.
+	SetEnabled(ctx context.Context, userID int64, enabled bool) error
+
+	// ReplaceRecoveryCodes discards any recovery codes previously issued
+	// to userID and stores codeHashes in their place.
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+
+	// SetLastVerifiedStep conditionally advances userID's last-verified
+	// TOTP step to step, atomically: the UPDATE only applies if step is
+	// still greater than whatever's currently stored, so two concurrent
+	// calls racing to consume the same (or an already-consumed) step
+	// can't both succeed. It reports whether the update applied; false
+	// means the step was already consumed (by a racing call or a replay)
+	// and the caller must reject the code rather than accept it.
+	SetLastVerifiedStep(ctx context.Context, userID int64, step int64) (bool, error)
+
+	// DeleteCredential removes userID's credential and any outstanding
+	// recovery codes, turning MFA fully off - the reverse of Enroll.
+	DeleteCredential(ctx context.Context, userID int64) error
+
+	// ConsumeRecoveryCode marks the recovery code matching codeHash used,
+	// reporting false (not an error) if it doesn't exist or was already
+	// used - every code works exactly once.
+	ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+}
+
+// RegistrationTokenRepository persists admin-issued invite tokens that
+// gate the signup flow. Only a token's SHA-256 hash is ever stored.
+type RegistrationTokenRepository interface {
+	Create(ctx context.Context, token *models.RegistrationToken) error
+	GetByID(ctx context.Context, id string) (*models.RegistrationToken, error)
+	GetAll(ctx context.Context) ([]models.RegistrationToken, error)
+	DeleteByID(ctx context.Context, id string) error
+
+	// UpdateLimits lets an admin extend or shrink a still-unused invite
+	// without having to issue a new one.
+	UpdateLimits(ctx context.Context, id string, usesAllowed int, expiresAt time.Time) error
+
+	// Redeem atomically consumes one use of the token matching tokenHash,
+	// returning it with UsesCompleted already incremented. It fails if the
+	// token doesn't exist, is expired, or has no uses left.
+	Redeem(ctx context.Context, tokenHash string) (*models.RegistrationToken, error)
+}
+
+// DeviceAuthRepository persists RFC 8628 device authorization requests.
+// It's kept separate from TokenStore (rather than adding these methods
+// there) so the redis and in-memory TokenStore backends aren't forced to
+// implement a flow they don't support; device flow is Postgres-only for
+// now, the same scoping PasswordResetRepository and LoginAttemptRepository
+// already use. Only a device_code's SHA-256 hash is ever stored; user_code
+// is short-lived and low-entropy by design, so it's kept plaintext.
+type DeviceAuthRepository interface {
+	Create(ctx context.Context, auth *models.DeviceAuthorization) error
+	GetByUserCode(ctx context.Context, userCode string) (*models.DeviceAuthorization, error)
+
+	// Approve marks the request matching userCode as approved by userID.
+	// It fails if the request doesn't exist, is expired, or was already
+	// approved or denied.
+	Approve(ctx context.Context, userCode string, userID int64) error
+	Deny(ctx context.Context, userCode string) error
+
+	// Poll looks up the request matching deviceCodeHash and reports
+	// whether the caller is polling faster than IntervalSeconds allows.
+	// When tooSoon is true, auth is still returned so the caller can tell
+	// pending from slow-down; LastPolledAt is updated as a side effect
+	// whenever the poll isn't rejected for being too soon.
+	Poll(ctx context.Context, deviceCodeHash string) (auth *models.DeviceAuthorization, tooSoon bool, err error)
+}
+
+// OAuthClientRepository looks up registered OIDC relying parties from the
+// oauth_clients table: redirect URI allow-list, allowed grant types, and
+// a bcrypt hash of the client secret for confidential clients. Like
+// DeviceAuthRepository, it's Postgres-only and assumes oauth_clients
+// already exists - no migration ships in this tree, the same convention
+// MFARepo's doc comment documents for mfa_credentials.
+type OAuthClientRepository interface {
+	GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error)
+}
+
+// AuthRequestRepository persists authorization-code-flow requests and the
+// single-use codes issued against them. It's kept separate from
+// DeviceAuthRepository, even though both model a short-lived code a user
+// approves, because the two flows have different shapes (PKCE challenge
+// and redirect_uri here, a user-typed code there). Only a code's SHA-256
+// hash is ever stored, the same convention DeviceAuthRepository uses for
+// device_code. Assumes oauth_authorization_requests already exists.
+type AuthRequestRepository interface {
+	Create(ctx context.Context, req *models.AuthorizationRequest) error
+
+	// GetByCodeHash looks up the request matching codeHash, for validating
+	// and then consuming it at /token.
+	GetByCodeHash(ctx context.Context, codeHash string) (*models.AuthorizationRequest, error)
+
+	// Consume marks id's code used. It fails if the code was already
+	// consumed, so a code can never be redeemed twice even under a
+	// concurrent /token race.
+	Consume(ctx context.Context, id string) error
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+const loginAttemptColumns = `
+  id, email, remote_ip, failure_count, locked_until, last_failure_at, updated_at
+`
+
+type LoginAttemptRepo struct {
+	DB *sql.DB
+}
+
+func NewLoginAttemptRepo(db *sql.DB) LoginAttemptRepository {
+	return &LoginAttemptRepo{DB: db}
+}
+
+// GetByEmailIP retrieves the failed-login counter for an (email, remote IP)
+// pair, or nil if none has been recorded yet.
+func (r *LoginAttemptRepo) GetByEmailIP(ctx context.Context, email, remoteIP string) (*models.LoginAttempt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM login_attempts WHERE email = $1 AND remote_ip = $2`, loginAttemptColumns)
+
+	var attempt models.LoginAttempt
+	var lockedUntil sql.NullTime
+
+	err := r.DB.QueryRowContext(ctx, query, email, remoteIP).Scan(
+		&attempt.ID,
+		&attempt.Email,
+		&attempt.RemoteIP,
+		&attempt.FailureCount,
+		&lockedUntil,
+		&attempt.LastFailureAt,
+		&attempt.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		slog.Error("failed to query login attempt",
+			"error", err,
+			"query", query,
+			"email", email,
+			"method", "LoginAttemptRepo.GetByEmailIP")
+		return nil, fmt.Errorf("querying login attempt: %w", err)
+	}
+
+	if lockedUntil.Valid {
+		attempt.LockedUntil = &lockedUntil.Time
+	}
+
+	return &attempt, nil
+}
+
+// Upsert records a failed-login counter, inserting a new row for a first
+// failure or updating the existing one for the same (email, remote IP).
+func (r *LoginAttemptRepo) Upsert(ctx context.Context, attempt *models.LoginAttempt) error {
+	stmt := `INSERT INTO login_attempts (email, remote_ip, failure_count, locked_until, last_failure_at, updated_at)
+             VALUES ($1, $2, $3, $4, $5, NOW())
+             ON CONFLICT (email, remote_ip) DO UPDATE SET
+               failure_count = EXCLUDED.failure_count,
+               locked_until = EXCLUDED.locked_until,
+               last_failure_at = EXCLUDED.last_failure_at,
+               updated_at = NOW()
+             RETURNING id`
+
+	err := r.DB.QueryRowContext(ctx, stmt,
+		attempt.Email,
+		attempt.RemoteIP,
+		attempt.FailureCount,
+		attempt.LockedUntil,
+		attempt.LastFailureAt,
+	).Scan(&attempt.ID)
+	if err != nil {
+		slog.Error("failed to upsert login attempt",
+			"error", err,
+			"query", stmt,
+			"email", attempt.Email,
+			"method", "LoginAttemptRepo.Upsert")
+		return fmt.Errorf("upserting login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// Reset clears the failed-login counter after a successful authentication.
+func (r *LoginAttemptRepo) Reset(ctx context.Context, email, remoteIP string) error {
+	stmt := `DELETE FROM login_attempts WHERE email = $1 AND remote_ip = $2`
+
+	if _, err := r.DB.ExecContext(ctx, stmt, email, remoteIP); err != nil {
+		slog.Error("failed to reset login attempt",
+			"error", err,
+			"query", stmt,
+			"email", email,
+			"method", "LoginAttemptRepo.Reset")
+		return fmt.Errorf("resetting login attempt: %w", err)
+	}
+
+	return nil
+}
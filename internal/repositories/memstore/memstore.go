@@ -0,0 +1,136 @@
+// Package memstore implements repositories.TokenStore in memory, for unit
+// tests and local development that don't want a Postgres or Redis
+// dependency just to exercise token issuance and revocation.
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+)
+
+type MemTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]models.TokenMetadata
+}
+
+func NewMemTokenStore() repositories.TokenStore {
+	return &MemTokenStore{tokens: make(map[string]models.TokenMetadata)}
+}
+
+func (s *MemTokenStore) SaveTokenMetadata(ctx context.Context, metadata *models.TokenMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[metadata.ID] = *metadata
+	return nil
+}
+
+func (s *MemTokenStore) GetTokenMetadata(ctx context.Context, tokenID string) (*models.TokenMetadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metadata, ok := s.tokens[tokenID]
+	if !ok {
+		return nil, fmt.Errorf("token not found")
+	}
+	return &metadata, nil
+}
+
+func (s *MemTokenStore) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metadata, ok := s.tokens[tokenID]
+	if !ok {
+		// If token doesn't exist, consider it as revoked/invalid, matching TokenRepo.
+		return true, nil
+	}
+	return metadata.IsRevoked, nil
+}
+
+func (s *MemTokenStore) RevokeToken(ctx context.Context, tokenID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metadata, ok := s.tokens[tokenID]
+	if !ok {
+		return fmt.Errorf("token not found")
+	}
+	metadata.IsRevoked = true
+	s.tokens[tokenID] = metadata
+	return nil
+}
+
+func (s *MemTokenStore) RevokeTokenByID(ctx context.Context, tokenID string) error {
+	return s.RevokeToken(ctx, tokenID)
+}
+
+func (s *MemTokenStore) RevokeAllTokensForUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, metadata := range s.tokens {
+		if metadata.UserID == userID {
+			metadata.IsRevoked = true
+			s.tokens[id] = metadata
+		}
+	}
+	return nil
+}
+
+func (s *MemTokenStore) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, metadata := range s.tokens {
+		if metadata.FamilyID == familyID {
+			metadata.IsRevoked = true
+			s.tokens[id] = metadata
+		}
+	}
+	return nil
+}
+
+func (s *MemTokenStore) UpdateLastUsed(ctx context.Context, tokenID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metadata, ok := s.tokens[tokenID]
+	if !ok {
+		// Don't fail validation over a token that's already gone.
+		return nil
+	}
+	metadata.LastUsedAt = time.Now()
+	s.tokens[tokenID] = metadata
+	return nil
+}
+
+func (s *MemTokenStore) CleanupExpiredTokens(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, metadata := range s.tokens {
+		if metadata.ExpiresAt.Before(now) {
+			delete(s.tokens, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemTokenStore) GetAllTokensForUser(ctx context.Context, userID string) ([]models.TokenMetadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tokens []models.TokenMetadata
+	for _, metadata := range s.tokens {
+		if metadata.UserID == userID {
+			tokens = append(tokens, metadata)
+		}
+	}
+	return tokens, nil
+}
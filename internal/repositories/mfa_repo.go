@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+// MFARepo is the Postgres MFARepository, backed by mfa_credentials and
+// mfa_recovery_codes tables. As with AuditRepo, no migration file for
+// either table ships in this tree - they're assumed to already exist in
+// the target database.
+type MFARepo struct {
+	DB *sql.DB
+}
+
+func NewMFARepo(db *sql.DB) MFARepository {
+	return &MFARepo{DB: db}
+}
+
+// GetCredential returns userID's enrolled credential, or nil if they
+// haven't started enrollment.
+func (r *MFARepo) GetCredential(ctx context.Context, userID int64) (*models.MFACredential, error) {
+	query := `SELECT user_id, secret, enabled, last_verified_step, created_at FROM mfa_credentials WHERE user_id = $1`
+
+	var cred models.MFACredential
+	err := r.DB.QueryRowContext(ctx, query, userID).Scan(
+		&cred.UserID,
+		&cred.Secret,
+		&cred.Enabled,
+		&cred.LastVerifiedStep,
+		&cred.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		slog.Error("failed to query mfa credential",
+			"error", err,
+			"query", query,
+			"user_id", userID,
+			"method", "MFARepo.GetCredential")
+		return nil, fmt.Errorf("querying mfa credential: %w", err)
+	}
+
+	return &cred, nil
+}
+
+// SaveCredential inserts or replaces userID's credential. Re-enrolling
+// resets last_verified_step to 0 along with the secret, since a step
+// verified against the old secret has no bearing on the new one.
+func (r *MFARepo) SaveCredential(ctx context.Context, cred *models.MFACredential) error {
+	stmt := `INSERT INTO mfa_credentials (user_id, secret, enabled, last_verified_step, created_at)
+             VALUES ($1, $2, $3, 0, $4)
+             ON CONFLICT (user_id) DO UPDATE SET
+               secret = EXCLUDED.secret,
+               enabled = EXCLUDED.enabled,
+               last_verified_step = 0,
+               created_at = EXCLUDED.created_at`
+
+	if _, err := r.DB.ExecContext(ctx, stmt, cred.UserID, cred.Secret, cred.Enabled, cred.CreatedAt); err != nil {
+		slog.Error("failed to save mfa credential",
+			"error", err,
+			"query", stmt,
+			"user_id", cred.UserID,
+			"method", "MFARepo.SaveCredential")
+		return fmt.Errorf("saving mfa credential: %w", err)
+	}
+
+	return nil
+}
+
+// SetEnabled flips whether MFA is enforced at login for userID.
+func (r *MFARepo) SetEnabled(ctx context.Context, userID int64, enabled bool) error {
+	stmt := `UPDATE mfa_credentials SET enabled = $1 WHERE user_id = $2`
+
+	if _, err := r.DB.ExecContext(ctx, stmt, enabled, userID); err != nil {
+		slog.Error("failed to update mfa credential enabled state",
+			"error", err,
+			"query", stmt,
+			"user_id", userID,
+			"method", "MFARepo.SetEnabled")
+		return fmt.Errorf("updating mfa credential: %w", err)
+	}
+
+	return nil
+}
+
+// SetLastVerifiedStep conditionally advances userID's last-verified TOTP
+// step to step: the WHERE clause only matches (and the UPDATE only
+// applies) if the stored value is still behind step, so two concurrent
+// calls racing to consume the same step - or a replay of an already-used
+// one - can't both report success, mirroring AuthRequestRepo.Consume's
+// conditional-UPDATE pattern for single-use tokens.
+func (r *MFARepo) SetLastVerifiedStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	stmt := `UPDATE mfa_credentials SET last_verified_step = $1 WHERE user_id = $2 AND last_verified_step < $1`
+
+	result, err := r.DB.ExecContext(ctx, stmt, step, userID)
+	if err != nil {
+		slog.Error("failed to update mfa last verified step",
+			"error", err,
+			"query", stmt,
+			"user_id", userID,
+			"method", "MFARepo.SetLastVerifiedStep")
+		return false, fmt.Errorf("updating mfa last verified step: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("checking mfa last verified step update result: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteCredential removes userID's credential and any outstanding
+// recovery codes, in the same transaction.
+func (r *MFARepo) DeleteCredential(ctx context.Context, userID int64) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning delete credential transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		slog.Error("failed to clear mfa recovery codes",
+			"error", err,
+			"user_id", userID,
+			"method", "MFARepo.DeleteCredential")
+		return fmt.Errorf("clearing recovery codes: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_credentials WHERE user_id = $1`, userID); err != nil {
+		slog.Error("failed to delete mfa credential",
+			"error", err,
+			"user_id", userID,
+			"method", "MFARepo.DeleteCredential")
+		return fmt.Errorf("deleting mfa credential: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing delete credential transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes discards any recovery codes previously issued to
+// userID and stores codeHashes in their place, in the same transaction.
+func (r *MFARepo) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning recovery code transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		slog.Error("failed to clear old mfa recovery codes",
+			"error", err,
+			"user_id", userID,
+			"method", "MFARepo.ReplaceRecoveryCodes")
+		return fmt.Errorf("clearing recovery codes: %w", err)
+	}
+
+	stmt := `INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, NOW())`
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, stmt, userID, hash); err != nil {
+			slog.Error("failed to insert mfa recovery code",
+				"error", err,
+				"user_id", userID,
+				"method", "MFARepo.ReplaceRecoveryCodes")
+			return fmt.Errorf("inserting recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing recovery code transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeRecoveryCode marks the recovery code matching codeHash used,
+// reporting false (not an error) if it doesn't exist or was already used.
+func (r *MFARepo) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	stmt := `UPDATE mfa_recovery_codes SET used_at = NOW()
+             WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := r.DB.ExecContext(ctx, stmt, userID, codeHash)
+	if err != nil {
+		slog.Error("failed to consume mfa recovery code",
+			"error", err,
+			"user_id", userID,
+			"method", "MFARepo.ConsumeRecoveryCode")
+		return false, fmt.Errorf("consuming recovery code: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("checking recovery code consumption: %w", err)
+	}
+
+	return rows > 0, nil
+}
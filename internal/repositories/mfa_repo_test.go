@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMFARepo_SetLastVerifiedStep_RejectsReplay guards against a
+// regression where the UPDATE unconditionally advanced
+// last_verified_step, so a TOTP code replayed by a second, racing request
+// could be consumed twice. The conditional WHERE clause means a second
+// call for the same step matches zero rows, which SetLastVerifiedStep
+// must report back as applied=false.
+func TestMFARepo_SetLastVerifiedStep_RejectsReplay(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepo(db)
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE mfa_credentials SET last_verified_step = $1 WHERE user_id = $2 AND last_verified_step < $1`)).
+		WithArgs(int64(100), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE mfa_credentials SET last_verified_step = $1 WHERE user_id = $2 AND last_verified_step < $1`)).
+		WithArgs(int64(100), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := repo.SetLastVerifiedStep(ctx, 1, 100)
+	require.NoError(t, err)
+	assert.True(t, applied, "first call for a fresh step should apply")
+
+	applied, err = repo.SetLastVerifiedStep(ctx, 1, 100)
+	require.NoError(t, err)
+	assert.False(t, applied, "replayed call for an already-consumed step must not apply")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+const oauthClientColumns = `
+  client_id, client_secret_hash, name, redirect_uris, grant_types, created_at
+`
+
+type OAuthClientRepo struct {
+	DB *sql.DB
+}
+
+func NewOAuthClientRepo(db *sql.DB) OAuthClientRepository {
+	return &OAuthClientRepo{DB: db}
+}
+
+// GetByClientID looks up a registered client. redirect_uris and
+// grant_types are stored as comma-separated text columns, the same
+// convention env.EnvConfig.ConnectorsEnabled uses for its env var, rather
+// than a separate join table neither has enough cardinality to need.
+func (r *OAuthClientRepo) GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	query := fmt.Sprintf(`SELECT %s FROM oauth_clients WHERE client_id = $1`, oauthClientColumns)
+
+	var client models.OAuthClient
+	var redirectURIs, grantTypes string
+
+	err := r.DB.QueryRowContext(ctx, query, clientID).Scan(
+		&client.ClientID,
+		&client.ClientSecretHash,
+		&client.Name,
+		&redirectURIs,
+		&grantTypes,
+		&client.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("oauth client not found")
+		}
+		slog.Error("failed to query oauth client",
+			"error", err,
+			"query", query,
+			"client_id", clientID,
+			"method", "OAuthClientRepo.GetByClientID")
+		return nil, fmt.Errorf("querying oauth client: %w", err)
+	}
+
+	client.RedirectURIs = splitCommaList(redirectURIs)
+	client.GrantTypes = splitCommaList(grantTypes)
+
+	return &client, nil
+}
+
+// splitCommaList splits a comma-separated column value into trimmed,
+// non-empty entries, mirroring pkg/env's splitAndTrim helper for the same
+// shape of data.
+func splitCommaList(v string) []string {
+	if v == "" {
+		return nil
+	}
+
+	parts := strings.Split(v, ",")
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if trimmed := strings.TrimSpace(p); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+const passwordResetColumns = `
+  id, user_id, token_hash, expires_at, used_at, created_at
+`
+
+type PasswordResetRepo struct {
+	DB *sql.DB
+}
+
+func NewPasswordResetRepo(db *sql.DB) PasswordResetRepository {
+	return &PasswordResetRepo{DB: db}
+}
+
+// Create inserts a new password reset token row.
+func (r *PasswordResetRepo) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	stmt := `INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+             VALUES ($1, $2, $3, $4) RETURNING id`
+
+	err := r.DB.QueryRowContext(ctx, stmt,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+	if err != nil {
+		slog.Error("failed to create password reset token",
+			"error", err,
+			"query", stmt,
+			"user_id", token.UserID,
+			"method", "PasswordResetRepo.Create")
+		return fmt.Errorf("creating password reset token: %w", err)
+	}
+
+	slog.Debug("successfully created password reset token",
+		"token_id", token.ID,
+		"user_id", token.UserID)
+
+	return nil
+}
+
+// GetByTokenHash retrieves a reset token by the SHA-256 hash of its raw value.
+func (r *PasswordResetRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	query := fmt.Sprintf(`SELECT %s FROM password_reset_tokens WHERE token_hash = $1`, passwordResetColumns)
+
+	var token models.PasswordResetToken
+	var usedAt sql.NullTime
+
+	err := r.DB.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("password reset token not found")
+		}
+		slog.Error("failed to query password reset token",
+			"error", err,
+			"query", query,
+			"method", "PasswordResetRepo.GetByTokenHash")
+		return nil, fmt.Errorf("querying password reset token: %w", err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return &token, nil
+}
+
+// MarkUsed records that a reset token has been redeemed, so it cannot be used again.
+func (r *PasswordResetRepo) MarkUsed(ctx context.Context, id int64) error {
+	stmt := `UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1`
+
+	if _, err := r.DB.ExecContext(ctx, stmt, id); err != nil {
+		slog.Error("failed to mark password reset token as used",
+			"error", err,
+			"query", stmt,
+			"token_id", id,
+			"method", "PasswordResetRepo.MarkUsed")
+		return fmt.Errorf("marking password reset token used: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes reset tokens past their expiry, for periodic cleanup.
+func (r *PasswordResetRepo) DeleteExpired(ctx context.Context) error {
+	stmt := `DELETE FROM password_reset_tokens WHERE expires_at < NOW()`
+
+	if _, err := r.DB.ExecContext(ctx, stmt); err != nil {
+		slog.Error("failed to delete expired password reset tokens",
+			"error", err,
+			"query", stmt,
+			"method", "PasswordResetRepo.DeleteExpired")
+		return fmt.Errorf("deleting expired password reset tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+// Package redisstore implements repositories.TokenStore against Redis,
+// for deployments where a Postgres round-trip per request is too slow for
+// the revocation check the auth middleware runs on every call.
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	tokenKeyPrefix     = "token:"
+	revokedKeyPrefix   = "jti:"
+	userTokensPrefix   = "user:"
+	userTokensSuffix   = ":tokens"
+	familyTokensPrefix = "family:"
+	familyTokensSuffix = ":tokens"
+)
+
+type RedisTokenStore struct {
+	Client *redis.Client
+}
+
+func NewRedisTokenStore(client *redis.Client) repositories.TokenStore {
+	return &RedisTokenStore{Client: client}
+}
+
+func tokenKey(tokenID string) string {
+	return tokenKeyPrefix + tokenID
+}
+
+func revokedKey(tokenID string) string {
+	return revokedKeyPrefix + tokenID
+}
+
+func userTokensKey(userID string) string {
+	return userTokensPrefix + userID + userTokensSuffix
+}
+
+func familyTokensKey(familyID string) string {
+	return familyTokensPrefix + familyID + familyTokensSuffix
+}
+
+// SaveTokenMetadata stores metadata for a token, with a TTL matching its
+// expiry so expired entries are reclaimed by Redis without a cleanup job.
+func (s *RedisTokenStore) SaveTokenMetadata(ctx context.Context, metadata *models.TokenMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		slog.Error("failed to marshal token metadata",
+			"error", err,
+			"token_id", metadata.ID,
+			"method", "RedisTokenStore.SaveTokenMetadata")
+		return fmt.Errorf("marshaling token metadata: %w", err)
+	}
+
+	if err := s.Client.Set(ctx, tokenKey(metadata.ID), data, ttlUntil(metadata.ExpiresAt)).Err(); err != nil {
+		slog.Error("failed to save token metadata",
+			"error", err,
+			"token_id", metadata.ID,
+			"user_id", metadata.UserID,
+			"method", "RedisTokenStore.SaveTokenMetadata")
+		return fmt.Errorf("saving token metadata: %w", err)
+	}
+
+	// Track the token under its owner so RevokeAllTokensForUser can find it;
+	// EXPIREAT keeps the index from outliving the tokens it lists, and is
+	// only ever moved later, so a shorter-lived token can't cut it short.
+	extendIndexExpiry := func(key string) {
+		if ttl, err := s.Client.TTL(ctx, key).Result(); err == nil && ttl > time.Until(metadata.ExpiresAt) {
+			return
+		}
+		s.Client.ExpireAt(ctx, key, metadata.ExpiresAt)
+	}
+	if err := s.Client.SAdd(ctx, userTokensKey(metadata.UserID), metadata.ID).Err(); err != nil {
+		slog.Error("failed to index token for user",
+			"error", err,
+			"token_id", metadata.ID,
+			"user_id", metadata.UserID,
+			"method", "RedisTokenStore.SaveTokenMetadata")
+		return fmt.Errorf("indexing token for user: %w", err)
+	}
+	extendIndexExpiry(userTokensKey(metadata.UserID))
+
+	// Also index under its family, if it has one, so RevokeTokenFamily can
+	// find it without scanning every token the user owns.
+	if metadata.FamilyID != "" {
+		if err := s.Client.SAdd(ctx, familyTokensKey(metadata.FamilyID), metadata.ID).Err(); err != nil {
+			slog.Error("failed to index token for family",
+				"error", err,
+				"token_id", metadata.ID,
+				"family_id", metadata.FamilyID,
+				"method", "RedisTokenStore.SaveTokenMetadata")
+			return fmt.Errorf("indexing token for family: %w", err)
+		}
+		extendIndexExpiry(familyTokensKey(metadata.FamilyID))
+	}
+
+	slog.Debug("successfully saved token metadata",
+		"token_id", metadata.ID,
+		"user_id", metadata.UserID,
+		"token_type", metadata.TokenType)
+
+	return nil
+}
+
+// GetTokenMetadata retrieves metadata for a specific token.
+func (s *RedisTokenStore) GetTokenMetadata(ctx context.Context, tokenID string) (*models.TokenMetadata, error) {
+	data, err := s.Client.Get(ctx, tokenKey(tokenID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			slog.Warn("token metadata not found",
+				"token_id", tokenID,
+				"method", "RedisTokenStore.GetTokenMetadata")
+			return nil, fmt.Errorf("token not found")
+		}
+		slog.Error("failed to query token metadata",
+			"error", err,
+			"token_id", tokenID,
+			"method", "RedisTokenStore.GetTokenMetadata")
+		return nil, fmt.Errorf("querying token metadata: %w", err)
+	}
+
+	var metadata models.TokenMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		slog.Error("failed to decode token metadata",
+			"error", err,
+			"token_id", tokenID,
+			"method", "RedisTokenStore.GetTokenMetadata")
+		return nil, fmt.Errorf("decoding token metadata: %w", err)
+	}
+
+	return &metadata, nil
+}
+
+// IsTokenRevoked checks for the jti:<id> blacklist marker. A missing token
+// (expired or never issued) is treated as revoked, matching TokenRepo.
+func (s *RedisTokenStore) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	n, err := s.Client.Exists(ctx, revokedKey(tokenID)).Result()
+	if err != nil {
+		slog.Error("failed to check token revocation status",
+			"error", err,
+			"token_id", tokenID,
+			"method", "RedisTokenStore.IsTokenRevoked")
+		return false, fmt.Errorf("checking token revocation status: %w", err)
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	exists, err := s.Client.Exists(ctx, tokenKey(tokenID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("checking token existence: %w", err)
+	}
+	if exists == 0 {
+		slog.Warn("token not found when checking revocation status",
+			"token_id", tokenID,
+			"method", "RedisTokenStore.IsTokenRevoked")
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// RevokeToken blacklists tokenID by setting jti:<id>, expiring at the same
+// time the token itself would have, so the blacklist entry never outlives it.
+func (s *RedisTokenStore) RevokeToken(ctx context.Context, tokenID string) error {
+	expiresAt := time.Now().Add(24 * time.Hour)
+	if metadata, err := s.GetTokenMetadata(ctx, tokenID); err == nil {
+		expiresAt = metadata.ExpiresAt
+	}
+
+	if err := s.Client.Set(ctx, revokedKey(tokenID), "revoked", ttlUntil(expiresAt)).Err(); err != nil {
+		slog.Error("failed to revoke token",
+			"error", err,
+			"token_id", tokenID,
+			"method", "RedisTokenStore.RevokeToken")
+		return fmt.Errorf("revoking token: %w", err)
+	}
+
+	slog.Info("successfully revoked token", "token_id", tokenID)
+	return nil
+}
+
+// RevokeTokenByID marks a token as revoked by its ID (same as RevokeToken).
+func (s *RedisTokenStore) RevokeTokenByID(ctx context.Context, tokenID string) error {
+	return s.RevokeToken(ctx, tokenID)
+}
+
+// RevokeAllTokensForUser revokes every token tracked under user:<id>:tokens.
+func (s *RedisTokenStore) RevokeAllTokensForUser(ctx context.Context, userID string) error {
+	tokenIDs, err := s.Client.SMembers(ctx, userTokensKey(userID)).Result()
+	if err != nil {
+		slog.Error("failed to list tokens for user",
+			"error", err,
+			"user_id", userID,
+			"method", "RedisTokenStore.RevokeAllTokensForUser")
+		return fmt.Errorf("listing tokens for user: %w", err)
+	}
+
+	for _, tokenID := range tokenIDs {
+		if err := s.RevokeToken(ctx, tokenID); err != nil {
+			slog.Error("failed to revoke token for user",
+				"error", err,
+				"token_id", tokenID,
+				"user_id", userID,
+				"method", "RedisTokenStore.RevokeAllTokensForUser")
+			return fmt.Errorf("revoking token %s: %w", tokenID, err)
+		}
+	}
+
+	slog.Info("successfully revoked all tokens for user",
+		"user_id", userID,
+		"tokens_revoked", len(tokenIDs))
+
+	return nil
+}
+
+// RevokeTokenFamily revokes every token tracked under family:<id>:tokens.
+func (s *RedisTokenStore) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	tokenIDs, err := s.Client.SMembers(ctx, familyTokensKey(familyID)).Result()
+	if err != nil {
+		slog.Error("failed to list tokens for family",
+			"error", err,
+			"family_id", familyID,
+			"method", "RedisTokenStore.RevokeTokenFamily")
+		return fmt.Errorf("listing tokens for family: %w", err)
+	}
+
+	for _, tokenID := range tokenIDs {
+		if err := s.RevokeToken(ctx, tokenID); err != nil {
+			slog.Error("failed to revoke token for family",
+				"error", err,
+				"token_id", tokenID,
+				"family_id", familyID,
+				"method", "RedisTokenStore.RevokeTokenFamily")
+			return fmt.Errorf("revoking token %s: %w", tokenID, err)
+		}
+	}
+
+	slog.Info("successfully revoked token family",
+		"family_id", familyID,
+		"tokens_revoked", len(tokenIDs))
+
+	return nil
+}
+
+// UpdateLastUsed updates the last used timestamp for a token. A token whose
+// metadata has already expired is silently ignored, same as TokenRepo: this
+// shouldn't fail token validation.
+func (s *RedisTokenStore) UpdateLastUsed(ctx context.Context, tokenID string) error {
+	metadata, err := s.GetTokenMetadata(ctx, tokenID)
+	if err != nil {
+		slog.Warn("no token found to update last used timestamp",
+			"token_id", tokenID,
+			"method", "RedisTokenStore.UpdateLastUsed")
+		return nil
+	}
+
+	metadata.LastUsedAt = time.Now()
+	return s.SaveTokenMetadata(ctx, metadata)
+}
+
+// CleanupExpiredTokens is a no-op: every key this store writes carries its
+// own EXPIREAT, so Redis reclaims expired entries without a sweep.
+func (s *RedisTokenStore) CleanupExpiredTokens(ctx context.Context) error {
+	return nil
+}
+
+// GetAllTokensForUser returns all tokens for a specific user still present
+// in Redis; tokens that have already expired are skipped.
+func (s *RedisTokenStore) GetAllTokensForUser(ctx context.Context, userID string) ([]models.TokenMetadata, error) {
+	tokenIDs, err := s.Client.SMembers(ctx, userTokensKey(userID)).Result()
+	if err != nil {
+		slog.Error("failed to list tokens for user",
+			"error", err,
+			"user_id", userID,
+			"method", "RedisTokenStore.GetAllTokensForUser")
+		return nil, fmt.Errorf("listing tokens for user: %w", err)
+	}
+
+	tokens := make([]models.TokenMetadata, 0, len(tokenIDs))
+	for _, tokenID := range tokenIDs {
+		metadata, err := s.GetTokenMetadata(ctx, tokenID)
+		if err != nil {
+			continue
+		}
+		tokens = append(tokens, *metadata)
+	}
+
+	slog.Debug("successfully retrieved tokens for user",
+		"user_id", userID,
+		"token_count", len(tokens))
+
+	return tokens, nil
+}
+
+// ttlUntil returns the duration until t, floored at one second so an
+// already-past expiry still gets written rather than rejected by Redis.
+func ttlUntil(t time.Time) time.Duration {
+	if ttl := time.Until(t); ttl > 0 {
+		return ttl
+	}
+	return time.Second
+}
@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+const registrationTokenColumns = `
+  id, token_hash, uses_allowed, uses_completed,
+  expires_at, pending_role, created_by, created_at
+`
+
+type RegistrationTokenRepo struct {
+	DB *sql.DB
+}
+
+func NewRegistrationTokenRepo(db *sql.DB) RegistrationTokenRepository {
+	return &RegistrationTokenRepo{DB: db}
+}
+
+// Create inserts a new registration token row. The caller is expected to
+// have already generated token.ID and hashed the raw token into TokenHash.
+func (r *RegistrationTokenRepo) Create(ctx context.Context, token *models.RegistrationToken) error {
+	stmt := `INSERT INTO registration_tokens (id, token_hash, uses_allowed, uses_completed, expires_at, pending_role, created_by, created_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := r.DB.ExecContext(ctx, stmt,
+		token.ID,
+		token.TokenHash,
+		token.UsesAllowed,
+		token.UsesCompleted,
+		token.ExpiresAt,
+		nullableString(token.PendingRole),
+		token.CreatedBy,
+		token.CreatedAt,
+	)
+	if err != nil {
+		slog.Error("failed to create registration token",
+			"error", err,
+			"query", stmt,
+			"token_id", token.ID,
+			"method", "RegistrationTokenRepo.Create")
+		return fmt.Errorf("creating registration token: %w", err)
+	}
+
+	slog.Info("successfully created registration token",
+		"token_id", token.ID,
+		"uses_allowed", token.UsesAllowed,
+		"created_by", token.CreatedBy)
+
+	return nil
+}
+
+// GetByID retrieves a single registration token by its ID, for the admin
+// list/detail endpoints.
+func (r *RegistrationTokenRepo) GetByID(ctx context.Context, id string) (*models.RegistrationToken, error) {
+	query := fmt.Sprintf(`SELECT %s FROM registration_tokens WHERE id = $1`, registrationTokenColumns)
+
+	token, err := scanRegistrationTokenRow(r.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("registration token not found")
+		}
+		slog.Error("failed to query registration token by ID",
+			"error", err,
+			"query", query,
+			"token_id", id,
+			"method", "RegistrationTokenRepo.GetByID")
+		return nil, fmt.Errorf("querying registration token: %w", err)
+	}
+
+	return token, nil
+}
+
+// GetAll returns every registration token, newest first.
+func (r *RegistrationTokenRepo) GetAll(ctx context.Context) ([]models.RegistrationToken, error) {
+	query := fmt.Sprintf(`SELECT %s FROM registration_tokens ORDER BY created_at DESC`, registrationTokenColumns)
+
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		slog.Error("failed to query registration tokens",
+			"error", err,
+			"query", query,
+			"method", "RegistrationTokenRepo.GetAll")
+		return nil, fmt.Errorf("querying registration tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []models.RegistrationToken
+	for rows.Next() {
+		token, err := scanRegistrationTokenRow(rows)
+		if err != nil {
+			slog.Error("failed to scan registration token row",
+				"error", err,
+				"method", "RegistrationTokenRepo.GetAll")
+			return nil, fmt.Errorf("scanning registration tokens: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning registration tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// DeleteByID removes a registration token, e.g. when an admin revokes an
+// unused invite.
+func (r *RegistrationTokenRepo) DeleteByID(ctx context.Context, id string) error {
+	stmt := `DELETE FROM registration_tokens WHERE id = $1`
+
+	result, err := r.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		slog.Error("failed to delete registration token",
+			"error", err,
+			"query", stmt,
+			"token_id", id,
+			"method", "RegistrationTokenRepo.DeleteByID")
+		return fmt.Errorf("deleting registration token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking delete result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("registration token not found")
+	}
+
+	return nil
+}
+
+// UpdateLimits changes how many uses a token allows and/or when it
+// expires, e.g. to extend an invite that hasn't been redeemed yet.
+func (r *RegistrationTokenRepo) UpdateLimits(ctx context.Context, id string, usesAllowed int, expiresAt time.Time) error {
+	stmt := `UPDATE registration_tokens SET uses_allowed = $1, expires_at = $2 WHERE id = $3`
+
+	result, err := r.DB.ExecContext(ctx, stmt, usesAllowed, expiresAt, id)
+	if err != nil {
+		slog.Error("failed to update registration token limits",
+			"error", err,
+			"query", stmt,
+			"token_id", id,
+			"method", "RegistrationTokenRepo.UpdateLimits")
+		return fmt.Errorf("updating registration token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking update result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("registration token not found")
+	}
+
+	return nil
+}
+
+// Redeem atomically consumes one use of the token matching tokenHash. The
+// SELECT ... FOR UPDATE inside the transaction ensures two concurrent
+// signups can't both succeed against the last remaining use.
+func (r *RegistrationTokenRepo) Redeem(ctx context.Context, tokenHash string) (*models.RegistrationToken, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning registration token redemption: %w", err)
+	}
+	defer tx.Rollback()
+
+	lockQuery := fmt.Sprintf(`SELECT %s FROM registration_tokens WHERE token_hash = $1 FOR UPDATE`, registrationTokenColumns)
+	token, err := scanRegistrationTokenRow(tx.QueryRowContext(ctx, lockQuery, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("registration token not found")
+		}
+		slog.Error("failed to lock registration token for redemption",
+			"error", err,
+			"method", "RegistrationTokenRepo.Redeem")
+		return nil, fmt.Errorf("querying registration token: %w", err)
+	}
+
+	if token.IsExpired() {
+		return nil, fmt.Errorf("registration token expired")
+	}
+	if token.IsExhausted() {
+		return nil, fmt.Errorf("registration token already used")
+	}
+
+	updateStmt := `UPDATE registration_tokens SET uses_completed = uses_completed + 1 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, updateStmt, token.ID); err != nil {
+		slog.Error("failed to increment registration token usage",
+			"error", err,
+			"token_id", token.ID,
+			"method", "RegistrationTokenRepo.Redeem")
+		return nil, fmt.Errorf("redeeming registration token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing registration token redemption: %w", err)
+	}
+
+	token.UsesCompleted++
+
+	slog.Info("redeemed registration token",
+		"token_id", token.ID,
+		"uses_completed", token.UsesCompleted,
+		"uses_allowed", token.UsesAllowed)
+
+	return token, nil
+}
+
+// ========================= Helper functions ============================
+
+type registrationTokenRow interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRegistrationTokenRow(row registrationTokenRow) (*models.RegistrationToken, error) {
+	var token models.RegistrationToken
+	var pendingRole sql.NullString
+
+	err := row.Scan(
+		&token.ID,
+		&token.TokenHash,
+		&token.UsesAllowed,
+		&token.UsesCompleted,
+		&token.ExpiresAt,
+		&pendingRole,
+		&token.CreatedBy,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if pendingRole.Valid {
+		token.PendingRole = pendingRole.String
+	}
+
+	return &token, nil
+}
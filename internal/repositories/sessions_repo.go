@@ -0,0 +1,266 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+const sessionColumns = `
+  id, user_id, device_id, refresh_token_id,
+  user_agent, ip_address, created_at, last_seen_at, expires_at
+`
+
+type SessionRepo struct {
+	DB *sql.DB
+}
+
+func NewSessionRepo(db *sql.DB) SessionRepository {
+	return &SessionRepo{DB: db}
+}
+
+// Create inserts a new active session row.
+func (r *SessionRepo) Create(ctx context.Context, session *models.Session) error {
+	stmt := `INSERT INTO sessions (id, user_id, device_id, refresh_token_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.DB.ExecContext(ctx, stmt,
+		session.ID,
+		session.UserID,
+		session.DeviceID,
+		session.RefreshTokenID,
+		session.UserAgent,
+		session.IPAddress,
+		session.CreatedAt,
+		session.LastSeenAt,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		slog.Error("failed to create session",
+			"error", err,
+			"query", stmt,
+			"user_id", session.UserID,
+			"method", "SessionRepo.Create")
+		return fmt.Errorf("creating session: %w", err)
+	}
+
+	slog.Debug("successfully created session",
+		"session_id", session.ID,
+		"user_id", session.UserID)
+
+	return nil
+}
+
+// GetByID retrieves a single session by its ID.
+func (r *SessionRepo) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	query := fmt.Sprintf(`SELECT %s FROM sessions WHERE id = $1`, sessionColumns)
+
+	session, err := scanSessionRow(r.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session not found")
+		}
+		slog.Error("failed to query session by ID",
+			"error", err,
+			"query", query,
+			"session_id", id,
+			"method", "SessionRepo.GetByID")
+		return nil, fmt.Errorf("querying session: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetAllForUser returns every active session for a user, newest first.
+func (r *SessionRepo) GetAllForUser(ctx context.Context, userID int64) ([]models.Session, error) {
+	query := fmt.Sprintf(`SELECT %s FROM sessions WHERE user_id = $1 ORDER BY created_at DESC`, sessionColumns)
+
+	rows, err := r.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		slog.Error("failed to query sessions for user",
+			"error", err,
+			"query", query,
+			"user_id", userID,
+			"method", "SessionRepo.GetAllForUser")
+		return nil, fmt.Errorf("querying sessions for user: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		session, err := scanSessionRow(rows)
+		if err != nil {
+			slog.Error("failed to scan session row",
+				"error", err,
+				"user_id", userID,
+				"method", "SessionRepo.GetAllForUser")
+			return nil, fmt.Errorf("scanning sessions: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// CountForUser returns how many active sessions a user currently has.
+func (r *SessionRepo) CountForUser(ctx context.Context, userID int64) (int64, error) {
+	query := `SELECT COUNT(*) FROM sessions WHERE user_id = $1`
+
+	var count int64
+	if err := r.DB.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		slog.Error("failed to count sessions for user",
+			"error", err,
+			"query", query,
+			"user_id", userID,
+			"method", "SessionRepo.CountForUser")
+		return 0, fmt.Errorf("counting sessions for user: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetOldestForUser returns the least-recently-seen session for a user, used
+// to evict when MaxActiveSessions is exceeded.
+func (r *SessionRepo) GetOldestForUser(ctx context.Context, userID int64) (*models.Session, error) {
+	query := fmt.Sprintf(`SELECT %s FROM sessions WHERE user_id = $1 ORDER BY last_seen_at ASC LIMIT 1`, sessionColumns)
+
+	session, err := scanSessionRow(r.DB.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no sessions found for user")
+		}
+		slog.Error("failed to query oldest session for user",
+			"error", err,
+			"query", query,
+			"user_id", userID,
+			"method", "SessionRepo.GetOldestForUser")
+		return nil, fmt.Errorf("querying oldest session: %w", err)
+	}
+
+	return session, nil
+}
+
+// DeleteByID removes a single session, e.g. when a user revokes one device.
+func (r *SessionRepo) DeleteByID(ctx context.Context, id string) error {
+	stmt := `DELETE FROM sessions WHERE id = $1`
+
+	result, err := r.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		slog.Error("failed to delete session",
+			"error", err,
+			"query", stmt,
+			"session_id", id,
+			"method", "SessionRepo.DeleteByID")
+		return fmt.Errorf("deleting session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking delete result: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+// DeleteAllForUser removes every session for a user, e.g. after a password reset.
+func (r *SessionRepo) DeleteAllForUser(ctx context.Context, userID int64) error {
+	stmt := `DELETE FROM sessions WHERE user_id = $1`
+
+	if _, err := r.DB.ExecContext(ctx, stmt, userID); err != nil {
+		slog.Error("failed to delete all sessions for user",
+			"error", err,
+			"query", stmt,
+			"user_id", userID,
+			"method", "SessionRepo.DeleteAllForUser")
+		return fmt.Errorf("deleting all sessions for user: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAllForUserExcept removes every session for a user other than the
+// one matching exceptDeviceID, used by DELETE /sessions (revoke-all-except-current).
+func (r *SessionRepo) DeleteAllForUserExcept(ctx context.Context, userID int64, exceptDeviceID string) error {
+	stmt := `DELETE FROM sessions WHERE user_id = $1 AND device_id != $2`
+
+	if _, err := r.DB.ExecContext(ctx, stmt, userID, exceptDeviceID); err != nil {
+		slog.Error("failed to delete other sessions for user",
+			"error", err,
+			"query", stmt,
+			"user_id", userID,
+			"method", "SessionRepo.DeleteAllForUserExcept")
+		return fmt.Errorf("deleting other sessions for user: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateLastSeen persists the last-seen timestamp for a session. Callers
+// that track activity in memory (services/sessions.Store) are expected to
+// call this on a flush interval rather than per-request.
+func (r *SessionRepo) UpdateLastSeen(ctx context.Context, id string, lastSeenAt time.Time) error {
+	stmt := `UPDATE sessions SET last_seen_at = $1 WHERE id = $2`
+
+	if _, err := r.DB.ExecContext(ctx, stmt, lastSeenAt, id); err != nil {
+		slog.Error("failed to update session last seen",
+			"error", err,
+			"query", stmt,
+			"session_id", id,
+			"method", "SessionRepo.UpdateLastSeen")
+		return fmt.Errorf("updating session last seen: %w", err)
+	}
+
+	return nil
+}
+
+// ========================= Helper functions ============================
+
+type sessionRow interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSessionRow(row sessionRow) (*models.Session, error) {
+	var session models.Session
+	var userAgent, ipAddress sql.NullString
+	var lastSeenAt, expiresAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.DeviceID,
+		&session.RefreshTokenID,
+		&userAgent,
+		&ipAddress,
+		&session.CreatedAt,
+		&lastSeenAt,
+		&expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if userAgent.Valid {
+		session.UserAgent = userAgent.String
+	}
+	if ipAddress.Valid {
+		session.IPAddress = ipAddress.String
+	}
+	if lastSeenAt.Valid {
+		session.LastSeenAt = lastSeenAt.Time
+	}
+	if expiresAt.Valid {
+		session.ExpiresAt = expiresAt.Time
+	}
+
+	return &session, nil
+}
@@ -3,8 +3,11 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/eupneart/auth-service/internal/models"
@@ -12,11 +15,17 @@ import (
 
 const tokenColumns = `
   id, user_id, token_type, device_id, client_id,
-  is_revoked, created_at, expires_at, last_used_at
+  is_revoked, created_at, expires_at, last_used_at,
+  parent_token_id, rotated_at, family_id
 `
 
 type TokenRepo struct {
 	DB *sql.DB
+
+	// SessionNotifier, if set, is told about revocations and activity so the
+	// in-memory session cache doesn't lag behind the token store until its
+	// next reap. Optional: left nil wherever no live-session cache exists.
+	SessionNotifier SessionNotifier
 }
 
 func NewTokenRepo(db *sql.DB) TokenStore {
@@ -25,8 +34,12 @@ func NewTokenRepo(db *sql.DB) TokenStore {
 
 // SaveTokenMetadata stores metadata for a token
 func (r *TokenRepo) SaveTokenMetadata(ctx context.Context, metadata *models.TokenMetadata) error {
-	stmt := `INSERT INTO token_metadata (id, user_id, token_type, device_id, client_id, is_revoked, created_at, expires_at, last_used_at) 
-             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	stmt := `INSERT INTO token_metadata (id, user_id, token_type, device_id, client_id, is_revoked, created_at, expires_at, last_used_at, parent_token_id, rotated_at, family_id)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+             ON CONFLICT (id) DO UPDATE SET
+               is_revoked = EXCLUDED.is_revoked,
+               last_used_at = EXCLUDED.last_used_at,
+               rotated_at = EXCLUDED.rotated_at`
 
 	_, err := r.DB.ExecContext(ctx, stmt,
 		metadata.ID,
@@ -38,6 +51,9 @@ func (r *TokenRepo) SaveTokenMetadata(ctx context.Context, metadata *models.Toke
 		metadata.CreatedAt,
 		metadata.ExpiresAt,
 		metadata.LastUsedAt,
+		nullableString(metadata.ParentTokenID),
+		metadata.RotatedAt,
+		nullableString(metadata.FamilyID),
 	)
 	if err != nil {
 		slog.Error("failed to save token metadata",
@@ -115,9 +131,12 @@ func (r *TokenRepo) IsTokenRevoked(ctx context.Context, tokenID string) (bool, e
 	return isRevoked, nil
 }
 
-// RevokeToken marks a token as revoked
+// RevokeToken marks a token as revoked. revoked_at_seq is stamped from the
+// token_metadata_revoked_at_seq sequence so RevocationDigestService can
+// pick up new revocations incrementally instead of rescanning every
+// revoked token on each rebuild.
 func (r *TokenRepo) RevokeToken(ctx context.Context, tokenID string) error {
-	stmt := `UPDATE token_metadata SET is_revoked = true WHERE id = $1`
+	stmt := `UPDATE token_metadata SET is_revoked = true, revoked_at_seq = nextval('token_metadata_revoked_at_seq') WHERE id = $1`
 
 	result, err := r.DB.ExecContext(ctx, stmt, tokenID)
 	if err != nil {
@@ -158,7 +177,7 @@ func (r *TokenRepo) RevokeTokenByID(ctx context.Context, tokenID string) error {
 
 // RevokeAllTokensForUser revokes all tokens for a specific user
 func (r *TokenRepo) RevokeAllTokensForUser(ctx context.Context, userID string) error {
-	stmt := `UPDATE token_metadata SET is_revoked = true WHERE user_id = $1 AND is_revoked = false`
+	stmt := `UPDATE token_metadata SET is_revoked = true, revoked_at_seq = nextval('token_metadata_revoked_at_seq') WHERE user_id = $1 AND is_revoked = false`
 
 	result, err := r.DB.ExecContext(ctx, stmt, userID)
 	if err != nil {
@@ -183,6 +202,43 @@ func (r *TokenRepo) RevokeAllTokensForUser(ctx context.Context, userID string) e
 		"user_id", userID,
 		"tokens_revoked", rowsAffected)
 
+	if r.SessionNotifier != nil {
+		if uid, err := strconv.ParseInt(userID, 10, 64); err == nil {
+			r.SessionNotifier.EvictUser(uid)
+		}
+	}
+
+	return nil
+}
+
+// RevokeTokenFamily revokes every token sharing familyID, i.e. the original
+// refresh token and everything it was rotated into.
+func (r *TokenRepo) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	stmt := `UPDATE token_metadata SET is_revoked = true, revoked_at_seq = nextval('token_metadata_revoked_at_seq') WHERE family_id = $1 AND is_revoked = false`
+
+	result, err := r.DB.ExecContext(ctx, stmt, familyID)
+	if err != nil {
+		slog.Error("failed to revoke token family",
+			"error", err,
+			"query", stmt,
+			"family_id", familyID,
+			"method", "TokenRepo.RevokeTokenFamily")
+		return fmt.Errorf("revoking token family: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected after revoking token family",
+			"error", err,
+			"family_id", familyID,
+			"method", "TokenRepo.RevokeTokenFamily")
+		return fmt.Errorf("checking revocation result: %w", err)
+	}
+
+	slog.Info("successfully revoked token family",
+		"family_id", familyID,
+		"tokens_revoked", rowsAffected)
+
 	return nil
 }
 
@@ -217,6 +273,10 @@ func (r *TokenRepo) UpdateLastUsed(ctx context.Context, tokenID string) error {
 		// Don't return error as this shouldn't fail token validation
 	}
 
+	if r.SessionNotifier != nil {
+		r.SessionNotifier.TouchRefreshToken(tokenID)
+	}
+
 	return nil
 }
 
@@ -346,13 +406,220 @@ func (r *TokenRepo) GetTokenCountForUser(ctx context.Context, userID string, tok
 	return count, nil
 }
 
+// ListTokens returns up to limit tokens matching filter, newest
+// created_at first, using a keyset cursor of "<created_at>,<id>" rather
+// than OFFSET so paging stays cheap deep into a large result set.
+func (r *TokenRepo) ListTokens(ctx context.Context, filter models.TokenFilter, cursor string, limit int) ([]models.TokenMetadata, string, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	where, args := tokenFilterClause(filter)
+
+	if cursor != "" {
+		createdAt, id, err := decodeTokenCursor(cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("decoding cursor: %w", err)
+		}
+		args = append(args, createdAt, id)
+		where = append(where, fmt.Sprintf(`(created_at, id) < ($%d, $%d)`, len(args)-1, len(args)))
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM token_metadata`, tokenColumns)
+	if len(where) > 0 {
+		query += " WHERE " + joinClauses(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT %d", limit+1)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("failed to query token metadata page",
+			"error", err,
+			"query", query,
+			"method", "TokenRepo.ListTokens")
+		return nil, "", fmt.Errorf("querying tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens, err := scanTokenMetadataRows(rows)
+	if err != nil {
+		return nil, "", fmt.Errorf("scanning tokens: %w", err)
+	}
+
+	if len(tokens) <= limit {
+		return tokens, "", nil
+	}
+
+	last := tokens[limit-1]
+	return tokens[:limit], encodeTokenCursor(last.CreatedAt, last.ID), nil
+}
+
+// StreamTokens sends every token matching filter on the returned channel
+// in created_at order, a page of pageSize rows at a time, closing the
+// channel when exhausted or when ctx is canceled.
+func (r *TokenRepo) StreamTokens(ctx context.Context, filter models.TokenFilter) (<-chan models.TokenMetadata, error) {
+	const pageSize = 200
+
+	out := make(chan models.TokenMetadata)
+	go func() {
+		defer close(out)
+
+		cursor := ""
+		for {
+			page, next, err := r.ListTokens(ctx, filter, cursor, pageSize)
+			if err != nil {
+				slog.Error("failed to fetch page during token stream",
+					"error", err,
+					"method", "TokenRepo.StreamTokens")
+				return
+			}
+
+			for _, token := range page {
+				select {
+				case out <- token:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if next == "" {
+				return
+			}
+			cursor = next
+		}
+	}()
+
+	return out, nil
+}
+
+// RevokedTokenIDsSince returns the IDs of tokens revoked (and not yet
+// expired) with revoked_at_seq greater than sinceSeq, so
+// RevocationDigestService can rebuild its Bloom filter incrementally.
+func (r *TokenRepo) RevokedTokenIDsSince(ctx context.Context, sinceSeq int64) ([]string, int64, error) {
+	query := `SELECT id, revoked_at_seq FROM token_metadata
+            WHERE is_revoked = true AND expires_at > $1 AND revoked_at_seq > $2
+            ORDER BY revoked_at_seq ASC`
+
+	rows, err := r.DB.QueryContext(ctx, query, time.Now(), sinceSeq)
+	if err != nil {
+		slog.Error("failed to query revoked token ids",
+			"error", err,
+			"query", query,
+			"method", "TokenRepo.RevokedTokenIDsSince")
+		return nil, sinceSeq, fmt.Errorf("querying revoked token ids: %w", err)
+	}
+	defer rows.Close()
+
+	maxSeq := sinceSeq
+	var ids []string
+	for rows.Next() {
+		var id string
+		var seq int64
+		if err := rows.Scan(&id, &seq); err != nil {
+			return nil, sinceSeq, fmt.Errorf("scanning revoked token id: %w", err)
+		}
+		ids = append(ids, id)
+		if seq > maxSeq {
+			maxSeq = seq
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, sinceSeq, fmt.Errorf("scanning revoked token ids: %w", err)
+	}
+
+	return ids, maxSeq, nil
+}
+
+// tokenFilterClause builds the WHERE predicates and matching args for a
+// TokenFilter; zero-valued fields are skipped.
+func tokenFilterClause(filter models.TokenFilter) ([]string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+
+	add := func(clause string, value interface{}) {
+		args = append(args, value)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.UserID != "" {
+		add(`user_id = $%d`, filter.UserID)
+	}
+	if filter.TokenType != "" {
+		add(`token_type = $%d`, filter.TokenType)
+	}
+	if filter.DeviceID != "" {
+		add(`device_id = $%d`, filter.DeviceID)
+	}
+	if filter.ClientID != "" {
+		add(`client_id = $%d`, filter.ClientID)
+	}
+	if filter.IsRevoked != nil {
+		add(`is_revoked = $%d`, *filter.IsRevoked)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		add(`created_at < $%d`, filter.CreatedBefore)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		add(`created_at > $%d`, filter.CreatedAfter)
+	}
+	if !filter.ExpiresBefore.IsZero() {
+		add(`expires_at < $%d`, filter.ExpiresBefore)
+	}
+	if !filter.ExpiresAfter.IsZero() {
+		add(`expires_at > $%d`, filter.ExpiresAfter)
+	}
+
+	return clauses, args
+}
+
+// joinClauses joins WHERE predicates with sep.
+func joinClauses(clauses []string, sep string) string {
+	return strings.Join(clauses, sep)
+}
+
+// encodeTokenCursor/decodeTokenCursor serialize the keyset cursor
+// ListTokens pages on. It's deliberately opaque (base64) so callers don't
+// build assumptions on its format.
+func encodeTokenCursor(createdAt time.Time, id string) string {
+	raw := fmt.Sprintf("%s,%s", createdAt.Format(time.RFC3339Nano), id)
+	return base64.URLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTokenCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("malformed cursor")
+	}
+
+	parts := strings.SplitN(string(raw), ",", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", fmt.Errorf("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("malformed cursor")
+	}
+
+	return createdAt, parts[1], nil
+}
+
 // ========================= Helper functions ============================
 
+// nullableString converts an empty string to a SQL NULL, for optional
+// text columns like parent_token_id.
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // scanTokenMetadata is a helper function to scan a single row into a TokenMetadata struct.
 func scanTokenMetadata(row *sql.Row) (*models.TokenMetadata, error) {
 	var metadata models.TokenMetadata
-	var deviceID, clientID sql.NullString
-	var lastUsedAt sql.NullTime
+	var deviceID, clientID, parentTokenID, familyID sql.NullString
+	var lastUsedAt, rotatedAt sql.NullTime
 
 	err := row.Scan(
 		&metadata.ID,
@@ -364,6 +631,9 @@ func scanTokenMetadata(row *sql.Row) (*models.TokenMetadata, error) {
 		&metadata.CreatedAt,
 		&metadata.ExpiresAt,
 		&lastUsedAt,
+		&parentTokenID,
+		&rotatedAt,
+		&familyID,
 	)
 	if err != nil {
 		return nil, err
@@ -379,6 +649,15 @@ func scanTokenMetadata(row *sql.Row) (*models.TokenMetadata, error) {
 	if lastUsedAt.Valid {
 		metadata.LastUsedAt = lastUsedAt.Time
 	}
+	if parentTokenID.Valid {
+		metadata.ParentTokenID = parentTokenID.String
+	}
+	if rotatedAt.Valid {
+		metadata.RotatedAt = &rotatedAt.Time
+	}
+	if familyID.Valid {
+		metadata.FamilyID = familyID.String
+	}
 
 	return &metadata, nil
 }
@@ -389,8 +668,8 @@ func scanTokenMetadataRows(rows *sql.Rows) ([]models.TokenMetadata, error) {
 
 	for rows.Next() {
 		var metadata models.TokenMetadata
-		var deviceID, clientID sql.NullString
-		var lastUsedAt sql.NullTime
+		var deviceID, clientID, parentTokenID, familyID sql.NullString
+		var lastUsedAt, rotatedAt sql.NullTime
 
 		if err := rows.Scan(
 			&metadata.ID,
@@ -402,6 +681,9 @@ func scanTokenMetadataRows(rows *sql.Rows) ([]models.TokenMetadata, error) {
 			&metadata.CreatedAt,
 			&metadata.ExpiresAt,
 			&lastUsedAt,
+			&parentTokenID,
+			&rotatedAt,
+			&familyID,
 		); err != nil {
 			slog.Error("failed to scan token metadata row",
 				"error", err,
@@ -419,6 +701,15 @@ func scanTokenMetadataRows(rows *sql.Rows) ([]models.TokenMetadata, error) {
 		if lastUsedAt.Valid {
 			metadata.LastUsedAt = lastUsedAt.Time
 		}
+		if parentTokenID.Valid {
+			metadata.ParentTokenID = parentTokenID.String
+		}
+		if rotatedAt.Valid {
+			metadata.RotatedAt = &rotatedAt.Time
+		}
+		if familyID.Valid {
+			metadata.FamilyID = familyID.String
+		}
 
 		tokens = append(tokens, metadata)
 	}
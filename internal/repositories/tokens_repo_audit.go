@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+// AuditingTokenStore decorates a TokenStore so RevokeToken,
+// RevokeTokenByID, RevokeAllTokensForUser, and RevokeTokenFamily each
+// produce a user_audit_log row. Unlike AuditingUserRepo, this can't share a
+// single transaction with the underlying mutation: TokenStore is implemented
+// by four backends (Postgres, Redis, BoltDB, in-memory) and only the Postgres one
+// speaks sql.Tx, so the audit row is written as a best-effort second step
+// right after the revocation commits, logged (not returned as an error)
+// if it fails, since the revocation itself already succeeded.
+type AuditingTokenStore struct {
+	TokenStore
+	audit  AuditRepository
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewAuditingTokenStore(store TokenStore, db *sql.DB, audit AuditRepository, logger *slog.Logger) *AuditingTokenStore {
+	return &AuditingTokenStore{TokenStore: store, db: db, audit: audit, logger: logger}
+}
+
+func (s *AuditingTokenStore) RevokeToken(ctx context.Context, tokenID string) error {
+	before, _ := s.TokenStore.GetTokenMetadata(ctx, tokenID)
+
+	if err := s.TokenStore.RevokeToken(ctx, tokenID); err != nil {
+		return err
+	}
+
+	s.auditRevocation(ctx, tokenID, before)
+	return nil
+}
+
+func (s *AuditingTokenStore) RevokeTokenByID(ctx context.Context, tokenID string) error {
+	before, _ := s.TokenStore.GetTokenMetadata(ctx, tokenID)
+
+	if err := s.TokenStore.RevokeTokenByID(ctx, tokenID); err != nil {
+		return err
+	}
+
+	s.auditRevocation(ctx, tokenID, before)
+	return nil
+}
+
+func (s *AuditingTokenStore) RevokeAllTokensForUser(ctx context.Context, userID string) error {
+	if err := s.TokenStore.RevokeAllTokensForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	a := actorFromContext(ctx)
+	targetID, _ := strconv.ParseInt(userID, 10, 64)
+
+	if err := s.audit.Create(ctx, s.db, &models.AuditLog{
+		ActorID:   a.id,
+		TargetID:  targetID,
+		Action:    models.AuditActionRevokeAll,
+		IP:        a.ip,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		s.logger.Error("failed to write audit log for RevokeAllTokensForUser",
+			"error", err,
+			"user_id", userID,
+			"method", "AuditingTokenStore.RevokeAllTokensForUser")
+	}
+
+	return nil
+}
+
+// RevokeTokenFamily audits a family revocation the same way
+// RevokeAllTokensForUser does, targeting whichever user owns the family (the
+// family's own tokens, looked up via familyID itself, since that's also a
+// valid token ID - the family's root token).
+func (s *AuditingTokenStore) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	root, _ := s.TokenStore.GetTokenMetadata(ctx, familyID)
+
+	if err := s.TokenStore.RevokeTokenFamily(ctx, familyID); err != nil {
+		return err
+	}
+
+	a := actorFromContext(ctx)
+	var targetID int64
+	if root != nil {
+		targetID, _ = strconv.ParseInt(root.UserID, 10, 64)
+	}
+
+	if err := s.audit.Create(ctx, s.db, &models.AuditLog{
+		ActorID:   a.id,
+		TargetID:  targetID,
+		Action:    models.AuditActionRevokeAll,
+		IP:        a.ip,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		s.logger.Error("failed to write audit log for RevokeTokenFamily",
+			"error", err,
+			"family_id", familyID,
+			"method", "AuditingTokenStore.RevokeTokenFamily")
+	}
+
+	return nil
+}
+
+// auditRevocation writes a DELETE audit row for a single revoked token.
+// before may be nil if the metadata lookup failed; the row is still
+// written, just without a Before snapshot.
+func (s *AuditingTokenStore) auditRevocation(ctx context.Context, tokenID string, before *models.TokenMetadata) {
+	a := actorFromContext(ctx)
+
+	var targetID int64
+	var beforeJSON json.RawMessage
+	if before != nil {
+		targetID, _ = strconv.ParseInt(before.UserID, 10, 64)
+		beforeJSON, _ = json.Marshal(before)
+	}
+
+	if err := s.audit.Create(ctx, s.db, &models.AuditLog{
+		ActorID:   a.id,
+		TargetID:  targetID,
+		Action:    models.AuditActionDelete,
+		Before:    beforeJSON,
+		IP:        a.ip,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		s.logger.Error("failed to write audit log for token revocation",
+			"error", err,
+			"token_id", tokenID,
+			"method", "AuditingTokenStore.auditRevocation")
+	}
+}
@@ -11,20 +11,20 @@ import (
 
 const userColumns = `
   id, email, first_name, last_name, password,
-  role, is_active, created_at, updated_at, last_login
+  role, is_active, created_at, updated_at, last_login, deleted_at
 `
 
 type UserRepo struct {
-	DB *sql.DB
+	DB SQLExecutor
 }
 
-func New(db *sql.DB) *UserRepo {
+func NewUserRepo(db SQLExecutor) *UserRepo {
 	return &UserRepo{DB: db}
 }
 
-// GetAll returns a slice of all users, sorted by last name
+// GetAll returns every non-deleted user, sorted by last name
 func (r *UserRepo) GetAll(ctx context.Context) ([]*models.User, error) {
-  query := fmt.Sprintf(`SELECT %s FROM users ORDER BY last_name`, userColumns)
+  query := fmt.Sprintf(`SELECT %s FROM users WHERE deleted_at IS NULL ORDER BY last_name`, userColumns)
 
 	// Execute query
 	rows, err := r.DB.QueryContext(ctx, query)
@@ -36,9 +36,9 @@ func (r *UserRepo) GetAll(ctx context.Context) ([]*models.User, error) {
   return scanUsers(rows)
 }
 
-// GetById returns one user by id
-func (r *UserRepo) GetById(ctx context.Context, id int) (*models.User, error) {
-  query := fmt.Sprintf(`SELECT %s FROM users WHERE id = $1`, userColumns)
+// GetByID returns one non-deleted user by id
+func (r *UserRepo) GetByID(ctx context.Context, id int64) (*models.User, error) {
+  query := fmt.Sprintf(`SELECT %s FROM users WHERE id = $1 AND deleted_at IS NULL`, userColumns)
 
 	row := r.DB.QueryRowContext(ctx, query, id)
 
@@ -50,9 +50,9 @@ func (r *UserRepo) GetById(ctx context.Context, id int) (*models.User, error) {
 	return usr, nil
 }
 
-// GetByEmail returns one user by email
+// GetByEmail returns one non-deleted user by email
 func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
-  query := fmt.Sprintf(`SELECT %s FROM users WHERE email = $1`, userColumns)
+  query := fmt.Sprintf(`SELECT %s FROM users WHERE email = $1 AND deleted_at IS NULL`, userColumns)
 
 	row := r.DB.QueryRowContext(ctx, query, email)
 
@@ -64,7 +64,11 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User,
 	return usr, nil
 }
 
-// Update one user in the database, using the user information
+// Update writes u's fields onto the matching row. It's meant for a
+// fully-populated User: is_active is always written, so a caller that
+// builds a partial User around just the field it means to change (e.g.
+// just Password) will silently reset is_active to false. For that, use
+// UpdatePassword instead.
 func (r *UserRepo) Update(ctx context.Context, u models.User) error {
 	type field struct {
 		name  string      // Column name in the database
@@ -77,6 +81,7 @@ func (r *UserRepo) Update(ctx context.Context, u models.User) error {
     {"email", u.Email, u.Email != ""},
     {"first_name", u.FirstName, u.FirstName != ""},
     {"last_name", u.LastName, u.LastName != ""},
+    {"password", u.Password, u.Password != ""},
     {"role", u.Role, u.Role != ""},
     {"is_active", u.IsActive, true}, // is_active field will always be included
     {"last_login", u.LastLogin, u.LastLogin != time.Time{}},
@@ -114,11 +119,29 @@ func (r *UserRepo) Update(ctx context.Context, u models.User) error {
 	return nil
 }
 
-// DeleteByID one user from the database, by ID
-func (r *UserRepo) DeleteByID(ctx context.Context, id int) error {
-	stmt := `DELETE FROM users WHERE id = $1`
+// UpdatePassword sets userID's password hash, touching no other column
+// besides updated_at -
+// unlike Update, it can't be misused to reset is_active (or anything
+// else) to its zero value just because the caller only had a new hash on
+// hand.
+func (r *UserRepo) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	stmt := `UPDATE users SET password = $1, updated_at = $2 WHERE id = $3`
 
-	_, err := r.DB.ExecContext(ctx, stmt, id)
+	_, err := r.DB.ExecContext(ctx, stmt, passwordHash, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("updating user password: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByID soft-deletes one user by ID, stamping deleted_at instead of
+// removing the row, so audit history and foreign keys referencing the
+// user stay intact.
+func (r *UserRepo) DeleteByID(ctx context.Context, id int64) error {
+	stmt := `UPDATE users SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	_, err := r.DB.ExecContext(ctx, stmt, time.Now(), id)
 	if err != nil {
     return fmt.Errorf("deleting user by id: %w", err)
 	}
@@ -127,13 +150,13 @@ func (r *UserRepo) DeleteByID(ctx context.Context, id int) error {
 }
 
 // Insert a single user into the DB
-func (r *UserRepo) Insert(ctx context.Context, u models.User) (int, error) {
+func (r *UserRepo) Insert(ctx context.Context, u models.User) (int64, error) {
 	// sql statement
-  stmt := `INSERT INTO users (email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login) 
+  stmt := `INSERT INTO users (email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login)
              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
 
 	// execute sql statement
-	var newId int
+	var newId int64
   err := r.DB.QueryRowContext(ctx, stmt,
 		u.Email,
 		u.FirstName,
@@ -171,6 +194,7 @@ func scanUsers(rows *sql.Rows) ([]*models.User, error) {
 			&usr.CreatedAt,
 			&usr.UpdatedAt,
       &usr.LastLogin,
+      &usr.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -200,6 +224,7 @@ func scanUser(row *sql.Row) (*models.User, error) {
 		&usr.CreatedAt,
 		&usr.UpdatedAt,
 		&usr.LastLogin,
+    &usr.DeletedAt,
 	)
   if err != nil {
     return nil, err
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+)
+
+// AuditingUserRepo decorates UserRepo so every mutating call (Insert,
+// Update, UpdatePassword, DeleteByID) writes a matching user_audit_log row in the same
+// transaction as the mutation it describes - the whole point being that
+// a audit row never exists without the change it documents, or vice
+// versa. Reads pass straight through to the wrapped repo. The actor
+// recorded on each row comes from WithActor on ctx; callers that never
+// set one are audited with a nil actor_id, as a system-initiated change.
+type AuditingUserRepo struct {
+	repo  *UserRepo
+	db    *sql.DB
+	audit AuditRepository
+}
+
+func NewAuditingUserRepo(repo *UserRepo, db *sql.DB, audit AuditRepository) *AuditingUserRepo {
+	return &AuditingUserRepo{repo: repo, db: db, audit: audit}
+}
+
+func (r *AuditingUserRepo) GetAll(ctx context.Context) ([]*models.User, error) {
+	return r.repo.GetAll(ctx)
+}
+
+func (r *AuditingUserRepo) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	return r.repo.GetByID(ctx, id)
+}
+
+func (r *AuditingUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.repo.GetByEmail(ctx, email)
+}
+
+func (r *AuditingUserRepo) Insert(ctx context.Context, u models.User) (int64, error) {
+	a := actorFromContext(ctx)
+
+	var newID int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		txRepo := &UserRepo{DB: tx}
+
+		id, err := txRepo.Insert(ctx, u)
+		if err != nil {
+			return err
+		}
+		newID = id
+
+		u.ID = newID
+		after, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+
+		return r.audit.Create(ctx, tx, &models.AuditLog{
+			ActorID:   a.id,
+			TargetID:  newID,
+			Action:    models.AuditActionInsert,
+			After:     after,
+			IP:        a.ip,
+			CreatedAt: time.Now(),
+		})
+	})
+
+	return newID, err
+}
+
+// Update audits the mutation as a LOGIN, rather than an UPDATE, when
+// LastLogin is the only user field whose stored value changed (comparing
+// the row read before and after the write) - the pattern
+// AuthHandler.Authenticate uses to stamp a successful login, since Update
+// itself has no separate "this is a login" signal to key off of.
+func (r *AuditingUserRepo) Update(ctx context.Context, u models.User) error {
+	a := actorFromContext(ctx)
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		txRepo := &UserRepo{DB: tx}
+
+		before, err := txRepo.GetByID(ctx, u.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := txRepo.Update(ctx, u); err != nil {
+			return err
+		}
+
+		after, err := txRepo.GetByID(ctx, u.ID)
+		if err != nil {
+			return err
+		}
+
+		beforeJSON, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		afterJSON, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+
+		action := models.AuditActionUpdate
+		if !after.LastLogin.Equal(before.LastLogin) &&
+			after.Email == before.Email && after.FirstName == before.FirstName &&
+			after.LastName == before.LastName && after.Password == before.Password &&
+			after.Role == before.Role && after.IsActive == before.IsActive {
+			action = models.AuditActionLogin
+		}
+
+		return r.audit.Create(ctx, tx, &models.AuditLog{
+			ActorID:   a.id,
+			TargetID:  u.ID,
+			Action:    action,
+			Before:    beforeJSON,
+			After:     afterJSON,
+			IP:        a.ip,
+			CreatedAt: time.Now(),
+		})
+	})
+}
+
+// UpdatePassword always audits as an UPDATE, never a LOGIN - it's the
+// dedicated narrow path ResetPassword and the opportunistic-rehash flow
+// use instead of building a partial User for Update, so it never goes
+// through Update's login-detection heuristic at all.
+func (r *AuditingUserRepo) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	a := actorFromContext(ctx)
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		txRepo := &UserRepo{DB: tx}
+
+		before, err := txRepo.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := txRepo.UpdatePassword(ctx, userID, passwordHash); err != nil {
+			return err
+		}
+
+		after, err := txRepo.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		beforeJSON, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		afterJSON, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+
+		return r.audit.Create(ctx, tx, &models.AuditLog{
+			ActorID:   a.id,
+			TargetID:  userID,
+			Action:    models.AuditActionUpdate,
+			Before:    beforeJSON,
+			After:     afterJSON,
+			IP:        a.ip,
+			CreatedAt: time.Now(),
+		})
+	})
+}
+
+func (r *AuditingUserRepo) DeleteByID(ctx context.Context, id int64) error {
+	a := actorFromContext(ctx)
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		txRepo := &UserRepo{DB: tx}
+
+		before, err := txRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := txRepo.DeleteByID(ctx, id); err != nil {
+			return err
+		}
+
+		beforeJSON, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+
+		return r.audit.Create(ctx, tx, &models.AuditLog{
+			ActorID:   a.id,
+			TargetID:  id,
+			Action:    models.AuditActionDelete,
+			Before:    beforeJSON,
+			IP:        a.ip,
+			CreatedAt: time.Now(),
+		})
+	})
+}
+
+// inTx runs fn inside a transaction, committing on success and rolling
+// back on any error (including a panic, which it re-panics after
+// rollback).
+func (r *AuditingUserRepo) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
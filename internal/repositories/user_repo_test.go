@@ -23,19 +23,19 @@ func TestUserRepo_GetAll(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	repo := New(db)
+	repo := NewUserRepo(db)
 
 	// Mock rows returned by the database
 	rows := sqlmock.NewRows([]string{
-		"id", "email", "first_name", "last_name", "password", 
-		"role", "is_active", "created_at", "updated_at", "last_login",
+		"id", "email", "first_name", "last_name", "password",
+		"role", "is_active", "created_at", "updated_at", "last_login", "deleted_at",
 	}).
-		AddRow(1, "test@example.com", "John", "Doe", "password", RoleUser, true, time.Now(), time.Now(), time.Now()).
-		AddRow(2, "test2@example.com", "Jane", "Doe", "password", RoleUser, true, time.Now(), time.Now(), time.Now())
+		AddRow(1, "test@example.com", "John", "Doe", "password", RoleUser, true, time.Now(), time.Now(), time.Now(), nil).
+		AddRow(2, "test2@example.com", "Jane", "Doe", "password", RoleUser, true, time.Now(), time.Now(), time.Now(), nil)
 
 	// Expectations
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT id, email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login FROM users ORDER BY last_name`)).
+		`SELECT id, email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login, deleted_at FROM users WHERE deleted_at IS NULL ORDER BY last_name`)).
 		WillReturnRows(rows)
 
 	// Call method
@@ -44,7 +44,7 @@ func TestUserRepo_GetAll(t *testing.T) {
 	// Assertions
 	require.NoError(t, err)
 	assert.Len(t, users, 2)
-	assert.Equal(t, 1, users[0].ID)
+	assert.Equal(t, int64(1), users[0].ID)
 	assert.Equal(t, "test@example.com", users[0].Email)
 	assert.Equal(t, "John", users[0].FirstName)
 	assert.Equal(t, "Doe", users[0].LastName)
@@ -52,33 +52,33 @@ func TestUserRepo_GetAll(t *testing.T) {
 	assert.True(t, users[0].IsActive)
 }
 
-func TestUserRepo_GetById(t *testing.T) {
+func TestUserRepo_GetByID(t *testing.T) {
 	// Mock DB setup
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
-	repo := New(db)
+	repo := NewUserRepo(db)
 
 	// Mock row returned by the database
 	row := sqlmock.NewRows([]string{
-		"id", "email", "first_name", "last_name", "password", 
-		"role", "is_active", "created_at", "updated_at", "last_login",
+		"id", "email", "first_name", "last_name", "password",
+		"role", "is_active", "created_at", "updated_at", "last_login", "deleted_at",
 	}).
-		AddRow(1, "test@example.com", "John", "Doe", "password", RoleUser, true, time.Now(), time.Now(), time.Now())
+		AddRow(1, "test@example.com", "John", "Doe", "password", RoleUser, true, time.Now(), time.Now(), time.Now(), nil)
 
 	// Expectations
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT id, email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login FROM users WHERE id = $1`)).
-		WithArgs(1).
+		`SELECT id, email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login, deleted_at FROM users WHERE id = $1 AND deleted_at IS NULL`)).
+		WithArgs(int64(1)).
 		WillReturnRows(row)
 
 	// Call method
-	user, err := repo.GetById(context.Background(), 1)
+	user, err := repo.GetByID(context.Background(), 1)
 
 	// Assertions
 	require.NoError(t, err)
-	assert.Equal(t, 1, user.ID)
+	assert.Equal(t, int64(1), user.ID)
 	assert.Equal(t, "test@example.com", user.Email)
 	assert.Equal(t, "John", user.FirstName)
 	assert.Equal(t, "Doe", user.LastName)
@@ -92,18 +92,18 @@ func TestUserRepo_GetByEmail(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	repo := New(db)
+	repo := NewUserRepo(db)
 
 	// Mock row returned by the database
 	row := sqlmock.NewRows([]string{
-		"id", "email", "first_name", "last_name", "password", 
-		"role", "is_active", "created_at", "updated_at", "last_login",
+		"id", "email", "first_name", "last_name", "password",
+		"role", "is_active", "created_at", "updated_at", "last_login", "deleted_at",
 	}).
-		AddRow(1, "test@example.com", "John", "Doe", "password", RoleUser, true, time.Now(), time.Now(), time.Now())
+		AddRow(1, "test@example.com", "John", "Doe", "password", RoleUser, true, time.Now(), time.Now(), time.Now(), nil)
 
 	// Expectations
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT id, email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login FROM users WHERE email = $1`)).
+		`SELECT id, email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login, deleted_at FROM users WHERE email = $1 AND deleted_at IS NULL`)).
 		WithArgs("test@example.com").
 		WillReturnRows(row)
 
@@ -112,7 +112,7 @@ func TestUserRepo_GetByEmail(t *testing.T) {
 
 	// Assertions
 	require.NoError(t, err)
-	assert.Equal(t, 1, user.ID)
+	assert.Equal(t, int64(1), user.ID)
 	assert.Equal(t, "test@example.com", user.Email)
 	assert.Equal(t, "John", user.FirstName)
 	assert.Equal(t, "Doe", user.LastName)
@@ -126,7 +126,7 @@ func TestUserRepo_Update(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	repo := New(db)
+	repo := NewUserRepo(db)
 
 	// Mock user data
 	user := models.User{
@@ -166,7 +166,7 @@ func TestUserRepo_Update_PartialFields(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	repo := New(db)
+	repo := NewUserRepo(db)
 
 	// Mock user data - only email and first_name set
 	user := models.User{
@@ -194,18 +194,68 @@ func TestUserRepo_Update_PartialFields(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestUserRepo_UpdatePassword_LeavesIsActiveUntouched guards against a
+// regression where a password change went through the generic Update
+// with a partial User{ID, Password}, whose field list always wrote
+// is_active - silently deactivating the account on every password reset
+// or rehash. UpdatePassword's UPDATE has no is_active column at all, so
+// it updates a user's password, then re-fetches the same row and asserts
+// both that the new hash took and that is_active (true beforehand) is
+// still true.
+func TestUserRepo_UpdatePassword_LeavesIsActiveUntouched(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	ctx := context.Background()
+
+	const newHash = "$2a$12$newlyHashedPassword"
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE users SET password = $1, updated_at = $2 WHERE id = $3`)).
+		WithArgs(
+			newHash,
+			sqlmock.AnyArg(), // updated_at
+			int64(1),
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.UpdatePassword(ctx, 1, newHash)
+	require.NoError(t, err)
+
+	row := sqlmock.NewRows([]string{
+		"id", "email", "first_name", "last_name", "password",
+		"role", "is_active", "created_at", "updated_at", "last_login", "deleted_at",
+	}).
+		AddRow(1, "test@example.com", "John", "Doe", newHash, RoleUser, true, time.Now(), time.Now(), time.Now(), nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT id, email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login, deleted_at FROM users WHERE id = $1 AND deleted_at IS NULL`)).
+		WithArgs(int64(1)).
+		WillReturnRows(row)
+
+	user, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, newHash, user.Password)
+	assert.True(t, user.IsActive, "UpdatePassword must not touch is_active")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUserRepo_DeleteByID(t *testing.T) {
 	// Mock DB setup
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
-	repo := New(db)
+	repo := NewUserRepo(db)
 
-	// Expectations
+	// Expectations: DeleteByID soft-deletes by stamping deleted_at, rather
+	// than removing the row.
 	mock.ExpectExec(regexp.QuoteMeta(
-		`DELETE FROM users WHERE id = $1`)).
-		WithArgs(1).
+		`UPDATE users SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), int64(1)).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Call method
@@ -237,7 +287,7 @@ func TestUserRepo_Insert(t *testing.T) {
 	}
 
 	// Expected values
-	mockID := 123
+	mockID := int64(123)
 
 	// Prepare mock query
 	mock.ExpectQuery(`INSERT INTO users \(email, first_name, last_name, password, role, is_active, created_at, updated_at, last_login\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\) RETURNING id`).
@@ -272,7 +322,7 @@ func TestUserRepo_Update_WithAdminRole(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	repo := New(db)
+	repo := NewUserRepo(db)
 
 	// Mock user data with admin role
 	user := models.User{
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher is the PasswordHasher UserService has always used. Keeping
+// it around as an explicit PasswordHasher implementation (rather than
+// inlining bcrypt calls) means a deployment can switch to Argon2idHasher
+// for new hashes while BcryptHasher.Verify-compatible hashes already in
+// the database keep working unchanged.
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher builds a BcryptHasher that hashes at cost. A cost of 0
+// falls back to bcrypt.DefaultCost.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Hash(plaintext string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(plaintext), h.Cost)
+	if err != nil {
+		return "", fmt.Errorf("hashing password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(hash, plaintext string) (bool, bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plaintext))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("comparing password hash: %w", err)
+	}
+
+	// A hash generated at a lower cost than we use today (e.g. before an
+	// operator raised Cost) is still valid but worth refreshing.
+	cost, err := bcrypt.Cost([]byte(hash))
+	needsRehash := err == nil && cost < h.Cost
+	return true, needsRehash, nil
+}
+
+func (h *BcryptHasher) Identifier() string {
+	return "bcrypt"
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+	"github.com/google/uuid"
+)
+
+// userCodeAlphabet excludes visually ambiguous characters (0/O, 1/I) so a
+// user reading verification_uri can type the code back accurately.
+const userCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// DefaultDeviceAuthLifetime and DefaultDeviceAuthPollInterval follow the
+// RFC 8628 section 3.2 examples.
+const (
+	DefaultDeviceAuthLifetime      = 10 * time.Minute
+	DefaultDeviceAuthPollInterval = 5
+)
+
+type deviceAuthService struct {
+	repo         repositories.DeviceAuthRepository
+	userService  *UserService
+	tokenService TokenService
+	logger       *slog.Logger
+}
+
+// NewDeviceAuthService creates a DeviceAuthService. userService is needed
+// to load the approving user once PollToken can issue a token pair.
+func NewDeviceAuthService(repo repositories.DeviceAuthRepository, userService *UserService, tokenService TokenService, logger *slog.Logger) DeviceAuthService {
+	return &deviceAuthService{repo: repo, userService: userService, tokenService: tokenService, logger: logger}
+}
+
+// StartAuthorization creates a new device/user code pair good for
+// DefaultDeviceAuthLifetime, returning the raw device_code (shown to the
+// polling device exactly once) alongside the stored record.
+func (s *deviceAuthService) StartAuthorization(ctx context.Context, clientID string) (string, *models.DeviceAuthorization, error) {
+	rawDeviceCode, err := randomDeviceCode(32)
+	if err != nil {
+		s.logger.Error("failed to generate device code",
+			"error", err,
+			"method", "deviceAuthService.StartAuthorization")
+		return "", nil, fmt.Errorf("generating device code: %w", err)
+	}
+
+	userCode, err := randomUserCode(8)
+	if err != nil {
+		s.logger.Error("failed to generate user code",
+			"error", err,
+			"method", "deviceAuthService.StartAuthorization")
+		return "", nil, fmt.Errorf("generating user code: %w", err)
+	}
+
+	auth := &models.DeviceAuthorization{
+		ID:              uuid.New().String(),
+		DeviceCodeHash:  hashDeviceCode(rawDeviceCode),
+		UserCode:        userCode,
+		ClientID:        clientID,
+		Status:          models.DeviceAuthPending,
+		IntervalSeconds: DefaultDeviceAuthPollInterval,
+		ExpiresAt:       time.Now().Add(DefaultDeviceAuthLifetime),
+		CreatedAt:       time.Now(),
+	}
+
+	if err := s.repo.Create(ctx, auth); err != nil {
+		s.logger.Error("failed to store device authorization",
+			"error", err,
+			"method", "deviceAuthService.StartAuthorization")
+		return "", nil, fmt.Errorf("storing device authorization: %w", err)
+	}
+
+	s.logger.Info("started device authorization",
+		"device_auth_id", auth.ID,
+		"client_id", clientID)
+
+	return rawDeviceCode, auth, nil
+}
+
+// VerifyUserCode approves the request matching userCode on behalf of
+// userID, e.g. once the user confirms the prompt shown at
+// verification_uri.
+func (s *deviceAuthService) VerifyUserCode(ctx context.Context, userCode string, userID int64) error {
+	auth, err := s.repo.GetByUserCode(ctx, userCode)
+	if err != nil {
+		s.logger.Warn("device verification attempted with unknown user code",
+			"error", err,
+			"method", "deviceAuthService.VerifyUserCode")
+		return ErrUserCodeInvalid
+	}
+	if auth.IsExpired() {
+		return ErrDeviceAuthExpired
+	}
+
+	if err := s.repo.Approve(ctx, userCode, userID); err != nil {
+		s.logger.Warn("failed to approve device authorization",
+			"error", err,
+			"device_auth_id", auth.ID,
+			"method", "deviceAuthService.VerifyUserCode")
+		return ErrUserCodeInvalid
+	}
+
+	s.logger.Info("approved device authorization",
+		"device_auth_id", auth.ID,
+		"user_id", userID)
+
+	return nil
+}
+
+// DenyUserCode rejects the request matching userCode.
+func (s *deviceAuthService) DenyUserCode(ctx context.Context, userCode string) error {
+	if err := s.repo.Deny(ctx, userCode); err != nil {
+		s.logger.Warn("failed to deny device authorization",
+			"error", err,
+			"method", "deviceAuthService.DenyUserCode")
+		return ErrUserCodeInvalid
+	}
+
+	s.logger.Info("denied device authorization")
+	return nil
+}
+
+// PollToken checks whether rawDeviceCode has been approved yet, enforcing
+// the slow-down interval server-side via repo.Poll.
+func (s *deviceAuthService) PollToken(ctx context.Context, rawDeviceCode string) (string, string, error) {
+	auth, tooSoon, err := s.repo.Poll(ctx, hashDeviceCode(rawDeviceCode))
+	if err != nil {
+		s.logger.Warn("device token poll with unknown device code",
+			"error", err,
+			"method", "deviceAuthService.PollToken")
+		return "", "", ErrDeviceCodeInvalid
+	}
+	if tooSoon {
+		return "", "", ErrDeviceAuthSlowDown
+	}
+	if auth.IsExpired() {
+		return "", "", ErrDeviceAuthExpired
+	}
+
+	switch auth.Status {
+	case models.DeviceAuthDenied:
+		return "", "", ErrDeviceAuthAccessDenied
+	case models.DeviceAuthPending:
+		return "", "", ErrDeviceAuthPending
+	}
+
+	user, err := s.userService.GetByID(ctx, *auth.UserID)
+	if err != nil {
+		s.logger.Error("failed to load user for approved device authorization",
+			"error", err,
+			"device_auth_id", auth.ID,
+			"user_id", *auth.UserID,
+			"method", "deviceAuthService.PollToken")
+		return "", "", fmt.Errorf("loading user for device authorization: %w", err)
+	}
+
+	accessToken, refreshToken, err := s.tokenService.GenerateTokens(ctx, user, auth.ClientID)
+	if err != nil {
+		s.logger.Error("failed to generate tokens for device authorization",
+			"error", err,
+			"device_auth_id", auth.ID,
+			"method", "deviceAuthService.PollToken")
+		return "", "", fmt.Errorf("generating tokens: %w", err)
+	}
+
+	s.logger.Info("issued tokens for device authorization",
+		"device_auth_id", auth.ID,
+		"user_id", user.ID)
+
+	return accessToken, refreshToken, nil
+}
+
+// randomDeviceCode returns a cryptographically random, hex-encoded
+// device_code of n random bytes.
+func randomDeviceCode(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashDeviceCode returns the hex-encoded SHA-256 hash of a raw
+// device_code, the only form ever persisted.
+func hashDeviceCode(rawDeviceCode string) string {
+	sum := sha256.Sum256([]byte(rawDeviceCode))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomUserCode returns a random, human-typeable code of n characters
+// drawn from userCodeAlphabet, formatted as two hyphen-separated groups
+// (e.g. "WDJB-MJHT") to match the RFC 8628 appendix examples.
+func randomUserCode(n int) (string, error) {
+	code := make([]byte, n)
+	for i := range code {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[idx.Int64()]
+	}
+
+	half := n / 2
+	return string(code[:half]) + "-" + string(code[half:]), nil
+}
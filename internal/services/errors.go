@@ -14,4 +14,33 @@ var (
 	ErrMaxSessionsExceeded = errors.New("maximum number of sessions exceeded")
 	ErrUserNotFound = errors.New("user not found")
 	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrInvalidCredentials is returned by UserService.Authenticate for
+	// both an unknown email and a wrong password, so callers can't use the
+	// error itself to enumerate which accounts exist.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// Password reset errors
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrResetTokenUsed = errors.New("reset token has already been used")
+
+	// Login protection errors
+	ErrAccountLocked = errors.New("account temporarily locked due to too many failed login attempts")
+
+	// ErrRefreshTokenReused is returned when a refresh token that was
+	// already rotated is presented again, indicating it was stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+	// Registration token errors
+	ErrRegistrationTokenInvalid   = errors.New("invalid registration token")
+	ErrRegistrationTokenExpired   = errors.New("registration token has expired")
+	ErrRegistrationTokenExhausted = errors.New("registration token has no uses remaining")
+
+	// Device authorization flow errors (RFC 8628 section 3.5)
+	ErrDeviceAuthPending     = errors.New("authorization_pending")
+	ErrDeviceAuthSlowDown    = errors.New("slow_down")
+	ErrDeviceAuthAccessDenied = errors.New("access_denied")
+	ErrDeviceAuthExpired     = errors.New("device code has expired")
+	ErrDeviceCodeInvalid     = errors.New("invalid device code")
+	ErrUserCodeInvalid       = errors.New("invalid or expired user code")
 )
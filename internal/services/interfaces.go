@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"github.com/eupneart/auth-service/internal/models"
 )
@@ -9,7 +10,7 @@ import (
 // Interfaces for user service business logic operations
 type UserAuthenticator interface {
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
-	PasswordMatches(u *models.User, plainText string) (bool, error)
+	PasswordMatches(ctx context.Context, u *models.User, plainText string) (bool, error)
 }
 
 type UserFinder interface {
@@ -26,12 +27,158 @@ type UserModifier interface {
 
 // Interface for tokens service business logic operations
 type TokenService interface {
-	GenerateTokens(ctx context.Context, user *models.User) (accessToken, refreshToken string, err error)
+	GenerateTokens(ctx context.Context, user *models.User, deviceID string) (accessToken, refreshToken string, err error)
 	ValidateToken(ctx context.Context, tokenStr string) (*models.Claims, error)
 	RefreshAccessToken(ctx context.Context, refreshToken string) (accessToken string, err error)
 	RevokeToken(ctx context.Context, tokenStr string) error
+	RevokeTokenByID(ctx context.Context, tokenID string) error
   GetTokenMetadata(ctx context.Context, tokenID string) (*models.TokenMetadata, error)
 	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
 	RevokeAllTokensForUser(ctx context.Context, userID string) error
 	CleanupExpiredTokens(ctx context.Context) error
+	GetAllTokensForUser(ctx context.Context, userID string) ([]models.TokenMetadata, error)
+
+	// RotateRefreshToken exchanges a refresh token for a brand-new
+	// access+refresh pair, marking the old one rotated. Presenting a
+	// refresh token a second time after it was already rotated is
+	// treated as token theft: every refresh token in that token's rotation
+	// family is revoked (the user's other families are left alone) and
+	// ErrRefreshTokenReused is returned.
+	RotateRefreshToken(ctx context.Context, refreshToken string) (accessToken, newRefreshToken string, err error)
+
+	// Reauthenticate issues a fresh access token with AuthTime reset to
+	// now, for a caller who has just re-proven their password without
+	// going through a full login - their refresh token and session are
+	// left untouched.
+	Reauthenticate(ctx context.Context, user *models.User, deviceID string) (accessToken string, err error)
+
+	// Introspect implements RFC 7662 token introspection for resource
+	// servers: it never returns an error for an invalid, expired, or
+	// revoked token, only {Active: false}, so introspection can't be used
+	// to probe why a token failed.
+	Introspect(ctx context.Context, tokenStr string) (*models.IntrospectionResponse, error)
+
+	// RevokeTokenCascade revokes tokenStr; if it's a refresh token, it
+	// also revokes every token in the same family (see RotateRefreshToken),
+	// so a resource server that catches a compromised refresh token can
+	// shut the whole chain down in one call.
+	RevokeTokenCascade(ctx context.Context, tokenStr string) error
+
+	// CompleteMFAChallenge issues the real access+refresh pair for a user
+	// who has just passed MFAService verification against their
+	// mfa_challenge token, stamping amr=["pwd","otp"] and aal=2.
+	CompleteMFAChallenge(ctx context.Context, user *models.User, deviceID string) (accessToken, refreshToken string, err error)
+}
+
+// Interface for session management business logic operations
+type SessionService interface {
+	RecordSession(ctx context.Context, userID int64, deviceID, refreshTokenID, userAgent, ipAddress string, maxActiveSessions int) error
+	ListSessions(ctx context.Context, userID int64) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	RevokeAllExcept(ctx context.Context, userID int64, currentDeviceID string) error
+	RevokeAll(ctx context.Context, userID int64) error
+}
+
+// Interface for the forgot-password / reset-password business logic
+type PasswordResetService interface {
+	// RequestReset always returns nil even when email has no matching
+	// account, so callers can't use the response to enumerate users.
+	RequestReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, resetToken, newPassword string) error
+}
+
+// RegistrationTokenService issues and redeems admin-created invite tokens
+// that gate the signup flow, optionally pre-assigning the role the
+// resulting user is created with.
+type RegistrationTokenService interface {
+	// IssueToken creates a new invite good for usesAllowed signups,
+	// returning the raw token (shown to the admin exactly once) alongside
+	// the stored record.
+	IssueToken(ctx context.Context, usesAllowed int, expiresAt time.Time, pendingRole string, createdBy int64) (rawToken string, token *models.RegistrationToken, err error)
+	ListTokens(ctx context.Context) ([]models.RegistrationToken, error)
+	RevokeToken(ctx context.Context, id string) error
+
+	// UpdateToken extends or shrinks a still-unused invite's limits.
+	UpdateToken(ctx context.Context, id string, usesAllowed int, expiresAt time.Time) error
+
+	// Redeem consumes one use of rawToken, returning the token record so
+	// the caller can read PendingRole. Returns ErrRegistrationTokenInvalid,
+	// ErrRegistrationTokenExpired, or ErrRegistrationTokenExhausted.
+	Redeem(ctx context.Context, rawToken string) (*models.RegistrationToken, error)
+}
+
+// TokenAdminService exposes keyset-paginated and streaming reads over all
+// issued tokens, for an admin dashboard or export. It wraps
+// repositories.TokenAdminRepository, which only the Postgres TokenStore
+// backend provides.
+type TokenAdminService interface {
+	ListTokens(ctx context.Context, filter models.TokenFilter, cursor string, limit int) (tokens []models.TokenMetadata, nextCursor string, err error)
+	StreamTokens(ctx context.Context, filter models.TokenFilter) (<-chan models.TokenMetadata, error)
+}
+
+// DeviceAuthService implements the RFC 8628 OAuth 2.0 Device
+// Authorization Grant: a device without a browser (e.g. a TV or CLI)
+// starts a request, a user approves it on a second device via
+// verification_uri, and the first device polls until it's approved.
+type DeviceAuthService interface {
+	// StartAuthorization creates a new device/user code pair, returning
+	// the raw device_code (shown to the polling device exactly once)
+	// alongside the stored record.
+	StartAuthorization(ctx context.Context, clientID string) (rawDeviceCode string, auth *models.DeviceAuthorization, err error)
+
+	// VerifyUserCode approves the request matching userCode on behalf of
+	// userID, e.g. once the user confirms the prompt shown at
+	// verification_uri.
+	VerifyUserCode(ctx context.Context, userCode string, userID int64) error
+
+	// DenyUserCode rejects the request matching userCode.
+	DenyUserCode(ctx context.Context, userCode string) error
+
+	// PollToken checks whether rawDeviceCode has been approved yet. It
+	// returns a token pair once approved, or one of ErrDeviceAuthPending,
+	// ErrDeviceAuthSlowDown, ErrDeviceAuthAccessDenied, or
+	// ErrDeviceAuthExpired otherwise.
+	PollToken(ctx context.Context, rawDeviceCode string) (accessToken, refreshToken string, err error)
+}
+
+// MFAService manages TOTP-based multi-factor authentication: enrollment,
+// a per-user enabled flag, code verification, and single-use recovery
+// codes for when a user loses their authenticator.
+type MFAService interface {
+	// IsEnabled reports whether userID has completed enrollment and
+	// turned MFA on. GenerateTokens consults this to decide whether to
+	// issue a normal token pair or an mfa_challenge token.
+	IsEnabled(ctx context.Context, userID int64) (bool, error)
+
+	// Enroll generates a new TOTP secret and recovery codes for userID,
+	// returning an otpauth:// URI for the user's authenticator app to
+	// scan and the plaintext recovery codes - shown exactly once, only
+	// their hashes are stored. MFA stays disabled until Verify succeeds
+	// against a code generated from the new secret.
+	Enroll(ctx context.Context, userID int64, accountName string) (otpauthURI string, recoveryCodes []string, err error)
+
+	// Verify checks code against userID's enrolled TOTP secret. The first
+	// successful Verify after Enroll also flips MFA on for the user.
+	Verify(ctx context.Context, userID int64, code string) (bool, error)
+
+	// VerifyRecoveryCode consumes one of userID's recovery codes in place
+	// of a TOTP code; each code works exactly once.
+	VerifyRecoveryCode(ctx context.Context, userID int64, code string) (bool, error)
+
+	// Disable verifies code (a TOTP or recovery code) against userID's
+	// current enrollment and, if it checks out, turns MFA off and deletes
+	// the credential and any remaining recovery codes - the reverse of
+	// Enroll. A false result with a nil error means code didn't verify.
+	Disable(ctx context.Context, userID int64, code string) (bool, error)
+}
+
+// LoginProtectionService tracks failed logins per (email, remote IP) and
+// enforces progressive lockout, so Authenticate can blunt brute-force
+// guessing without involving every caller in the bookkeeping.
+type LoginProtectionService interface {
+	// CheckLocked returns whether (email, remoteIP) is currently locked
+	// out, and if so for how much longer.
+	CheckLocked(ctx context.Context, email, remoteIP string) (locked bool, retryAfter time.Duration, err error)
+	RecordFailure(ctx context.Context, email, remoteIP string) error
+	RecordSuccess(ctx context.Context, email, remoteIP string) error
 }
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/mail"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+)
+
+// maxLoginFailures is how many consecutive failures are tolerated before
+// the first lockout kicks in.
+const maxLoginFailures = 5
+
+// lockoutStages are the lockout durations applied once failures exceed
+// maxLoginFailures, escalating with each further failure and capping at
+// the last entry.
+var lockoutStages = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	24 * time.Hour,
+}
+
+type loginProtectionService struct {
+	repo      repositories.LoginAttemptRepository
+	mailer    mail.Mailer
+	userEmail func(ctx context.Context, email string) (*models.User, error)
+	logger    *slog.Logger
+}
+
+// NewLoginProtectionService creates a LoginProtectionService. userService
+// is used to look up the locked-out user so a lockout notice can be
+// emailed; a nil mailer simply skips that notification.
+func NewLoginProtectionService(repo repositories.LoginAttemptRepository, userService *UserService, mailer mail.Mailer, logger *slog.Logger) LoginProtectionService {
+	return &loginProtectionService{
+		repo:      repo,
+		mailer:    mailer,
+		userEmail: userService.GetByEmail,
+		logger:    logger,
+	}
+}
+
+// CheckLocked reports whether (email, remoteIP) is currently locked out.
+func (s *loginProtectionService) CheckLocked(ctx context.Context, email, remoteIP string) (bool, time.Duration, error) {
+	attempt, err := s.repo.GetByEmailIP(ctx, email, remoteIP)
+	if err != nil {
+		return false, 0, fmt.Errorf("checking login lockout: %w", err)
+	}
+	if attempt == nil || !attempt.IsLocked() {
+		return false, 0, nil
+	}
+
+	return true, time.Until(*attempt.LockedUntil), nil
+}
+
+// RecordFailure increments the failure counter for (email, remoteIP),
+// locking it out for an escalating duration once maxLoginFailures is
+// exceeded.
+func (s *loginProtectionService) RecordFailure(ctx context.Context, email, remoteIP string) error {
+	attempt, err := s.repo.GetByEmailIP(ctx, email, remoteIP)
+	if err != nil {
+		return fmt.Errorf("loading login attempt: %w", err)
+	}
+	if attempt == nil {
+		attempt = &models.LoginAttempt{Email: email, RemoteIP: remoteIP}
+	}
+
+	attempt.FailureCount++
+	attempt.LastFailureAt = time.Now()
+
+	if attempt.FailureCount > maxLoginFailures {
+		stage := attempt.FailureCount - maxLoginFailures - 1
+		if stage >= len(lockoutStages) {
+			stage = len(lockoutStages) - 1
+		}
+		lockedUntil := time.Now().Add(lockoutStages[stage])
+		attempt.LockedUntil = &lockedUntil
+
+		s.logger.Warn("account locked after repeated failed logins",
+			"email", email,
+			"remote_ip", remoteIP,
+			"failure_count", attempt.FailureCount,
+			"locked_until", lockedUntil,
+			"method", "loginProtectionService.RecordFailure")
+
+		s.notifyLockout(ctx, email, lockedUntil)
+	}
+
+	if err := s.repo.Upsert(ctx, attempt); err != nil {
+		return fmt.Errorf("saving login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the failure counter after a successful login.
+func (s *loginProtectionService) RecordSuccess(ctx context.Context, email, remoteIP string) error {
+	if err := s.repo.Reset(ctx, email, remoteIP); err != nil {
+		return fmt.Errorf("resetting login attempt: %w", err)
+	}
+	return nil
+}
+
+// notifyLockout emails the account owner that their account was locked,
+// best-effort: a mailer failure is logged but never fails the request.
+func (s *loginProtectionService) notifyLockout(ctx context.Context, email string, lockedUntil time.Time) {
+	if s.mailer == nil {
+		return
+	}
+
+	user, err := s.userEmail(ctx, email)
+	if err != nil || user == nil {
+		return
+	}
+
+	body := fmt.Sprintf("We locked your account after several failed login attempts. You can try again after %s.\n\nIf this wasn't you, consider resetting your password.", lockedUntil.Format(time.RFC1123))
+	if err := s.mailer.Send(ctx, user.Email, "Your account was temporarily locked", body); err != nil {
+		s.logger.Warn("failed to send account lockout notice",
+			"error", err,
+			"email", email,
+			"method", "loginProtectionService.notifyLockout")
+	}
+}
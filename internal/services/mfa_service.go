@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/crypto"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+var (
+	// ErrMFANotEnrolled is returned by Verify and Disable for a
+	// user who has never called Enroll.
+	ErrMFANotEnrolled = errors.New("mfa not enrolled for this user")
+)
+
+// recoveryCodeCount is how many single-use recovery codes Enroll issues.
+const recoveryCodeCount = 10
+
+// totpPeriod is the TOTP step size in seconds, per the RFC 6238 default
+// also configured below in Verify's ValidateOpts equivalent.
+const totpPeriod = 30
+
+type mfaService struct {
+	repo   repositories.MFARepository
+	issuer string
+	logger *slog.Logger
+
+	// encryptor encrypts Secret at rest when configured. Deployments that
+	// haven't set one (e.g. haven't provisioned MFA_ENCRYPTION_KEY) keep
+	// today's plaintext behavior rather than failing enrollment outright.
+	encryptor crypto.Encryptor
+}
+
+// NewMFAService creates an MFAService that names issuer in the otpauth://
+// URIs it generates, so a user's authenticator app shows which service
+// the entry belongs to. encryptor may be nil, in which case TOTP secrets
+// are stored in mfa_credentials as plaintext.
+func NewMFAService(repo repositories.MFARepository, issuer string, encryptor crypto.Encryptor, logger *slog.Logger) MFAService {
+	return &mfaService{repo: repo, issuer: issuer, encryptor: encryptor, logger: logger}
+}
+
+// encodeSecret prepares a freshly-generated TOTP secret for storage,
+// encrypting it when s.encryptor is configured.
+func (s *mfaService) encodeSecret(secret string) (string, error) {
+	if s.encryptor == nil {
+		return secret, nil
+	}
+	return s.encryptor.Encrypt([]byte(secret))
+}
+
+// decodeSecret reverses encodeSecret, recovering the plaintext TOTP
+// secret from what GetCredential returned.
+func (s *mfaService) decodeSecret(stored string) (string, error) {
+	if s.encryptor == nil {
+		return stored, nil
+	}
+	plaintext, err := s.encryptor.Decrypt(stored)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// IsEnabled reports whether userID has completed enrollment and turned
+// MFA on.
+func (s *mfaService) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	cred, err := s.repo.GetCredential(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return cred != nil && cred.Enabled, nil
+}
+
+// Enroll generates a new TOTP secret and recovery codes for userID.
+// Enrolling again replaces the stored secret and codes and saves the
+// credential with Enabled set to false, so MFA stays off until Verify
+// succeeds against the new secret; the old secret no longer verifies.
+func (s *mfaService) Enroll(ctx context.Context, userID int64, accountName string) (string, []string, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.issuer,
+		AccountName: accountName,
+	})
+	if err != nil {
+		s.logger.Error("failed to generate totp secret",
+			"error", err,
+			"user_id", userID,
+			"method", "MFAService.Enroll")
+		return "", nil, fmt.Errorf("generating totp secret: %w", err)
+	}
+
+	storedSecret, err := s.encodeSecret(key.Secret())
+	if err != nil {
+		s.logger.Error("failed to encrypt totp secret",
+			"error", err,
+			"user_id", userID,
+			"method", "MFAService.Enroll")
+		return "", nil, fmt.Errorf("encrypting totp secret: %w", err)
+	}
+
+	cred := &models.MFACredential{
+		UserID:    userID,
+		Secret:    storedSecret,
+		Enabled:   false,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.SaveCredential(ctx, cred); err != nil {
+		return "", nil, err
+	}
+
+	recoveryCodes, codeHashes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		s.logger.Error("failed to generate mfa recovery codes",
+			"error", err,
+			"user_id", userID,
+			"method", "MFAService.Enroll")
+		return "", nil, fmt.Errorf("generating recovery codes: %w", err)
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, codeHashes); err != nil {
+		return "", nil, err
+	}
+
+	s.logger.Info("mfa enrollment started", "user_id", userID, "method", "MFAService.Enroll")
+
+	return key.URL(), recoveryCodes, nil
+}
+
+// Verify checks code against userID's enrolled TOTP secret. The first
+// successful Verify after Enroll also flips MFA on for the user. A code is
+// only ever accepted once, even if it's replayed again within its own
+// 30-second window or the +/-1 step skew - see validateTOTPStep.
+func (s *mfaService) Verify(ctx context.Context, userID int64, code string) (bool, error) {
+	cred, err := s.repo.GetCredential(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if cred == nil {
+		return false, ErrMFANotEnrolled
+	}
+
+	secret, err := s.decodeSecret(cred.Secret)
+	if err != nil {
+		s.logger.Error("failed to decrypt totp secret",
+			"error", err,
+			"user_id", userID,
+			"method", "MFAService.Verify")
+		return false, fmt.Errorf("decrypting totp secret: %w", err)
+	}
+
+	step, valid := validateTOTPStep(code, secret, time.Now())
+	if !valid {
+		return false, nil
+	}
+
+	if cred.LastVerifiedStep != 0 && step <= cred.LastVerifiedStep {
+		s.logger.Warn("rejected replayed totp code", "user_id", userID, "method", "MFAService.Verify")
+		return false, nil
+	}
+
+	// SetLastVerifiedStep is the actual guard against replay, not the
+	// cred.LastVerifiedStep check above: its UPDATE only applies if step
+	// is still ahead of whatever's stored, so it also catches two
+	// concurrent Verify calls racing on the same (now-stale) cred.
+	applied, err := s.repo.SetLastVerifiedStep(ctx, userID, step)
+	if err != nil {
+		return false, err
+	}
+	if !applied {
+		s.logger.Warn("rejected replayed totp code", "user_id", userID, "method", "MFAService.Verify")
+		return false, nil
+	}
+
+	if !cred.Enabled {
+		if err := s.repo.SetEnabled(ctx, userID, true); err != nil {
+			return false, err
+		}
+		s.logger.Info("mfa enrollment completed", "user_id", userID, "method", "MFAService.Verify")
+	}
+
+	return true, nil
+}
+
+// validateTOTPStep checks code against every step in the +/-1 skew window
+// around now, the same window totp.ValidateCustom covered, returning
+// whichever absolute step number matched so Verify can reject a repeat of
+// that step later.
+func validateTOTPStep(code, secret string, now time.Time) (step int64, valid bool) {
+	current := now.Unix() / totpPeriod
+	for _, skew := range []int64{0, -1, 1} {
+		candidate := current + skew
+		generated, err := totp.GenerateCodeCustom(secret, time.Unix(candidate*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(generated), []byte(code)) == 1 {
+			return candidate, true
+		}
+	}
+	return 0, false
+}
+
+// Disable verifies code against userID's current enrollment and, if it
+// checks out, deletes the credential and any remaining recovery codes -
+// the reverse of Enroll. code may be either a TOTP code or a recovery
+// code, the same two options Verify's caller (/mfa/verify) accepts.
+func (s *mfaService) Disable(ctx context.Context, userID int64, code string) (bool, error) {
+	cred, err := s.repo.GetCredential(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if cred == nil {
+		return false, ErrMFANotEnrolled
+	}
+
+	valid, err := s.Verify(ctx, userID, code)
+	if err != nil {
+		return false, err
+	}
+	if !valid {
+		valid, err = s.VerifyRecoveryCode(ctx, userID, code)
+		if err != nil {
+			return false, err
+		}
+	}
+	if !valid {
+		return false, nil
+	}
+
+	if err := s.repo.DeleteCredential(ctx, userID); err != nil {
+		return false, err
+	}
+
+	s.logger.Info("mfa disabled", "user_id", userID, "method", "MFAService.Disable")
+
+	return true, nil
+}
+
+// VerifyRecoveryCode consumes one of userID's recovery codes in place of
+// a TOTP code; each code works exactly once.
+func (s *mfaService) VerifyRecoveryCode(ctx context.Context, userID int64, code string) (bool, error) {
+	hash := hashRecoveryCode(code)
+	consumed, err := s.repo.ConsumeRecoveryCode(ctx, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	if consumed {
+		s.logger.Warn("mfa recovery code consumed", "user_id", userID, "method", "MFAService.VerifyRecoveryCode")
+	}
+	return consumed, nil
+}
+
+// generateRecoveryCodes returns n plaintext recovery codes alongside the
+// SHA-256 hashes that are what actually get stored.
+func generateRecoveryCodes(n int) (codes []string, hashes []string, err error) {
+	codes = make([]string, n)
+	hashes = make([]string, n)
+
+	for i := 0; i < n; i++ {
+		var raw [10]byte
+		if _, err := rand.Read(raw[:]); err != nil {
+			return nil, nil, err
+		}
+		code := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw[:])
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,21 @@
+package services
+
+// PasswordHasher hashes and verifies passwords for storage, decoupling
+// UserService from any one hashing algorithm. Hash output is expected to
+// be self-describing (e.g. bcrypt's "$2a$<cost>$..." prefix, or the
+// PHC-style string Argon2idHasher produces) so Verify can recognize a
+// hash it didn't itself produce and still check it correctly.
+type PasswordHasher interface {
+	// Hash returns an encoded hash of plaintext suitable for storage.
+	Hash(plaintext string) (string, error)
+
+	// Verify reports whether plaintext matches hash. needsRehash is true
+	// when hash was produced by a weaker configuration (a lower cost, a
+	// legacy algorithm entirely) than this hasher would use today, so the
+	// caller can opportunistically migrate it to a fresh Hash result.
+	// needsRehash is only meaningful when ok is true.
+	Verify(hash, plaintext string) (ok bool, needsRehash bool, err error)
+
+	// Identifier names the algorithm this hasher produces, for logging.
+	Identifier() string
+}
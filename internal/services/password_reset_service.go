@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/mail"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+)
+
+// resetTokenLifetime is how long a forgot-password link stays valid.
+const resetTokenLifetime = time.Hour
+
+type passwordResetService struct {
+	repo        repositories.PasswordResetRepository
+	userService *UserService
+	tokenStore  repositories.TokenStore
+	mailer      mail.Mailer
+	baseURL     string
+	logger      *slog.Logger
+}
+
+// NewPasswordResetService creates a PasswordResetService. baseURL is the
+// public URL of the frontend's reset-password page, used to build the
+// link sent by mailer, e.g. "https://app.eupneart.com".
+func NewPasswordResetService(repo repositories.PasswordResetRepository, userService *UserService, tokenStore repositories.TokenStore, mailer mail.Mailer, baseURL string, logger *slog.Logger) PasswordResetService {
+	return &passwordResetService{
+		repo:        repo,
+		userService: userService,
+		tokenStore:  tokenStore,
+		mailer:      mailer,
+		baseURL:     baseURL,
+		logger:      logger,
+	}
+}
+
+// RequestReset generates a single-use reset token for email and sends it
+// via mailer. It always returns nil when email has no matching account,
+// so callers can't use the response to enumerate registered users.
+func (s *passwordResetService) RequestReset(ctx context.Context, email string) error {
+	user, err := s.userService.GetByEmail(ctx, email)
+	if err != nil || user == nil {
+		s.logger.Info("password reset requested for unknown email",
+			"email", email,
+			"method", "passwordResetService.RequestReset")
+		return nil
+	}
+
+	rawToken, err := randomResetToken(32)
+	if err != nil {
+		s.logger.Error("failed to generate password reset token",
+			"error", err,
+			"user_id", user.ID,
+			"method", "passwordResetService.RequestReset")
+		return fmt.Errorf("generating reset token: %w", err)
+	}
+
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashResetToken(rawToken),
+		ExpiresAt: time.Now().Add(resetTokenLifetime),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.Create(ctx, token); err != nil {
+		s.logger.Error("failed to store password reset token",
+			"error", err,
+			"user_id", user.ID,
+			"method", "passwordResetService.RequestReset")
+		return fmt.Errorf("storing reset token: %w", err)
+	}
+
+	resetLink := fmt.Sprintf("%s/reset-password?token=%s", s.baseURL, rawToken)
+	body := fmt.Sprintf("We received a request to reset your password. Use the link below within the next hour:\n\n%s\n\nIf you didn't request this, you can ignore this email.", resetLink)
+
+	if err := s.mailer.Send(ctx, user.Email, "Reset your password", body); err != nil {
+		s.logger.Error("failed to send password reset email",
+			"error", err,
+			"user_id", user.ID,
+			"method", "passwordResetService.RequestReset")
+		return fmt.Errorf("sending reset email: %w", err)
+	}
+
+	s.logger.Info("sent password reset email",
+		"user_id", user.ID,
+		"method", "passwordResetService.RequestReset")
+
+	return nil
+}
+
+// ResetPassword redeems a single-use reset token, setting the user's new
+// password and invalidating every outstanding refresh token and session.
+func (s *passwordResetService) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	token, err := s.repo.GetByTokenHash(ctx, hashResetToken(resetToken))
+	if err != nil {
+		s.logger.Warn("password reset attempted with unknown token",
+			"error", err,
+			"method", "passwordResetService.ResetPassword")
+		return ErrInvalidResetToken
+	}
+
+	if token.IsUsed() {
+		s.logger.Warn("password reset attempted with already-used token",
+			"token_id", token.ID,
+			"user_id", token.UserID,
+			"method", "passwordResetService.ResetPassword")
+		return ErrResetTokenUsed
+	}
+
+	if token.IsExpired() {
+		s.logger.Warn("password reset attempted with expired token",
+			"token_id", token.ID,
+			"user_id", token.UserID,
+			"method", "passwordResetService.ResetPassword")
+		return ErrInvalidResetToken
+	}
+
+	if err := s.userService.ResetPassword(ctx, &models.User{ID: token.UserID, Password: newPassword}); err != nil {
+		s.logger.Error("failed to reset password for redeemed token",
+			"error", err,
+			"token_id", token.ID,
+			"user_id", token.UserID,
+			"method", "passwordResetService.ResetPassword")
+		return fmt.Errorf("resetting password: %w", err)
+	}
+
+	// UserService.ResetPassword already revokes active sessions; also
+	// revoke every outstanding token so a stolen refresh token issued
+	// before the reset can't keep minting new access tokens.
+	if err := s.tokenStore.RevokeAllTokensForUser(ctx, strconv.FormatInt(token.UserID, 10)); err != nil {
+		s.logger.Warn("failed to revoke outstanding tokens after password reset",
+			"error", err,
+			"user_id", token.UserID,
+			"method", "passwordResetService.ResetPassword")
+	}
+
+	if err := s.repo.MarkUsed(ctx, token.ID); err != nil {
+		s.logger.Warn("failed to mark reset token as used",
+			"error", err,
+			"token_id", token.ID,
+			"user_id", token.UserID,
+			"method", "passwordResetService.ResetPassword")
+	}
+
+	s.logger.Info("password reset completed",
+		"user_id", token.UserID,
+		"method", "passwordResetService.ResetPassword")
+
+	return nil
+}
+
+// randomResetToken returns a cryptographically random, hex-encoded token
+// of n random bytes.
+func randomResetToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashResetToken returns the hex-encoded SHA-256 hash of a raw reset
+// token, the only form ever persisted.
+func hashResetToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
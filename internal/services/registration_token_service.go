@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+	"github.com/google/uuid"
+)
+
+type registrationTokenService struct {
+	repo   repositories.RegistrationTokenRepository
+	logger *slog.Logger
+}
+
+// NewRegistrationTokenService creates a RegistrationTokenService.
+func NewRegistrationTokenService(repo repositories.RegistrationTokenRepository, logger *slog.Logger) RegistrationTokenService {
+	return &registrationTokenService{repo: repo, logger: logger}
+}
+
+// IssueToken creates a new invite good for usesAllowed signups, returning
+// the raw token (shown to the admin exactly once) alongside the stored
+// record.
+func (s *registrationTokenService) IssueToken(ctx context.Context, usesAllowed int, expiresAt time.Time, pendingRole string, createdBy int64) (string, *models.RegistrationToken, error) {
+	rawToken, err := randomRegistrationToken(32)
+	if err != nil {
+		s.logger.Error("failed to generate registration token",
+			"error", err,
+			"method", "registrationTokenService.IssueToken")
+		return "", nil, fmt.Errorf("generating registration token: %w", err)
+	}
+
+	token := &models.RegistrationToken{
+		ID:          uuid.New().String(),
+		TokenHash:   hashRegistrationToken(rawToken),
+		UsesAllowed: usesAllowed,
+		ExpiresAt:   expiresAt,
+		PendingRole: pendingRole,
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.repo.Create(ctx, token); err != nil {
+		s.logger.Error("failed to store registration token",
+			"error", err,
+			"method", "registrationTokenService.IssueToken")
+		return "", nil, fmt.Errorf("storing registration token: %w", err)
+	}
+
+	s.logger.Info("issued registration token",
+		"token_id", token.ID,
+		"uses_allowed", usesAllowed,
+		"pending_role", pendingRole,
+		"created_by", createdBy)
+
+	return rawToken, token, nil
+}
+
+// ListTokens returns every registration token, for the admin UI.
+func (s *registrationTokenService) ListTokens(ctx context.Context) ([]models.RegistrationToken, error) {
+	tokens, err := s.repo.GetAll(ctx)
+	if err != nil {
+		s.logger.Error("failed to list registration tokens",
+			"error", err,
+			"method", "registrationTokenService.ListTokens")
+		return nil, fmt.Errorf("listing registration tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes a registration token, e.g. an invite that was sent
+// to the wrong person.
+func (s *registrationTokenService) RevokeToken(ctx context.Context, id string) error {
+	if err := s.repo.DeleteByID(ctx, id); err != nil {
+		s.logger.Warn("failed to revoke registration token",
+			"error", err,
+			"token_id", id,
+			"method", "registrationTokenService.RevokeToken")
+		return fmt.Errorf("revoking registration token: %w", err)
+	}
+
+	s.logger.Info("revoked registration token", "token_id", id)
+	return nil
+}
+
+// UpdateToken extends or shrinks a still-unused invite's limits.
+func (s *registrationTokenService) UpdateToken(ctx context.Context, id string, usesAllowed int, expiresAt time.Time) error {
+	if err := s.repo.UpdateLimits(ctx, id, usesAllowed, expiresAt); err != nil {
+		s.logger.Warn("failed to update registration token",
+			"error", err,
+			"token_id", id,
+			"method", "registrationTokenService.UpdateToken")
+		return fmt.Errorf("updating registration token: %w", err)
+	}
+
+	s.logger.Info("updated registration token limits",
+		"token_id", id,
+		"uses_allowed", usesAllowed)
+	return nil
+}
+
+// Redeem consumes one use of rawToken, so the signup handler can gate
+// account creation on it.
+func (s *registrationTokenService) Redeem(ctx context.Context, rawToken string) (*models.RegistrationToken, error) {
+	token, err := s.repo.Redeem(ctx, hashRegistrationToken(rawToken))
+	if err != nil {
+		s.logger.Warn("registration token redemption failed",
+			"error", err,
+			"method", "registrationTokenService.Redeem")
+		return nil, classifyRegistrationTokenError(err)
+	}
+
+	s.logger.Info("redeemed registration token",
+		"token_id", token.ID,
+		"uses_completed", token.UsesCompleted,
+		"uses_allowed", token.UsesAllowed)
+
+	return token, nil
+}
+
+// classifyRegistrationTokenError maps the repository's plain errors onto
+// the sentinel errors callers (e.g. the registration handler) switch on.
+func classifyRegistrationTokenError(err error) error {
+	switch err.Error() {
+	case "registration token expired":
+		return ErrRegistrationTokenExpired
+	case "registration token already used":
+		return ErrRegistrationTokenExhausted
+	default:
+		return ErrRegistrationTokenInvalid
+	}
+}
+
+// randomRegistrationToken returns a cryptographically random, hex-encoded
+// token of n random bytes.
+func randomRegistrationToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashRegistrationToken returns the hex-encoded SHA-256 hash of a raw
+// registration token, the only form ever persisted.
+func hashRegistrationToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,72 @@
+package revocationdigest
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloomFilter is a fixed-size Bloom filter over string items (token IDs).
+// It uses the Kirsch-Mitzenmacher technique to derive k hash functions
+// from two independent 64-bit hashes, rather than computing k separate
+// hashes per item.
+type bloomFilter struct {
+	bits []byte
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+}
+
+// newBloomFilter sizes a filter for expectedItems items at
+// falsePositiveRate, using the standard optimal-m/optimal-k formulas.
+func newBloomFilter(expectedItems int, falsePositiveRate float64) *bloomFilter {
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+
+	n := float64(expectedItems)
+	m := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 8 {
+		m = 8
+	}
+	k := uint64(math.Round((float64(m) / n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &bloomFilter{
+		bits: make([]byte, (m+7)/8),
+		m:    m,
+		k:    k,
+	}
+}
+
+func (f *bloomFilter) hashes(item string) (uint64, uint64) {
+	h1 := fnv.New64a()
+	_, _ = h1.Write([]byte(item))
+	sum1 := h1.Sum64()
+
+	h2 := fnv.New64a()
+	_, _ = h2.Write([]byte{0xff})
+	_, _ = h2.Write([]byte(item))
+	sum2 := h2.Sum64()
+
+	return sum1, sum2
+}
+
+func (f *bloomFilter) Add(item string) {
+	h1, h2 := f.hashes(item)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (f *bloomFilter) Test(item string) bool {
+	h1, h2 := f.hashes(item)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,241 @@
+// Package revocationdigest maintains a signed Bloom filter over currently
+// revoked, non-expired token IDs, rebuilt on a timer and cached in
+// memory, so resource servers can check revocation locally via GET
+// /.well-known/revocation-digest instead of paying a TokenStore
+// round-trip on every request. Most ticks only fold in what's newly
+// revoked since the last one, but every fullRebuildInterval the filter is
+// reconstructed from scratch off the complete current revoked-non-expired
+// set - without that, entries for tokens that later expire (and so drop
+// out of RevokedTokenIDsSince's result) would never leave the filter,
+// and its false-positive rate would climb toward 1 over a long-running
+// process. There's no persistence of the filter itself yet: a fresh
+// process starts from an empty filter and catches up via
+// RevokedTokenIDsSince on its first tick.
+package revocationdigest
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/keys"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+)
+
+// DefaultCapacity and DefaultFalsePositiveRate size the Bloom filter for
+// a moderately active deployment; tune via NewService for larger ones.
+const (
+	DefaultCapacity          = 100_000
+	DefaultFalsePositiveRate = 0.01
+	DefaultRebuildInterval   = 30 * time.Second
+
+	// DefaultFullRebuildInterval is how often rebuild reconstructs the
+	// Bloom filter from scratch off the complete revoked-non-expired set,
+	// instead of only folding in what's new since the last tick. It's
+	// deliberately much coarser than DefaultRebuildInterval: a full
+	// rebuild re-scans every currently-revoked token, not just the delta.
+	DefaultFullRebuildInterval = 1 * time.Hour
+)
+
+// Service owns the Bloom filter and its signed, versioned serialization.
+type Service struct {
+	repo                repositories.RevocationRepository
+	keyManager          *keys.Manager
+	logger              *slog.Logger
+	interval            time.Duration
+	fullRebuildInterval time.Duration
+	capacity            int
+	falsePositiveRate   float64
+
+	mu              sync.RWMutex
+	filter          *bloomFilter
+	lastSeq         int64
+	lastFullRebuild time.Time
+	digest          *models.RevocationDigest
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewService creates a Service sized for capacity items at
+// falsePositiveRate, rebuilding every interval and doing a full rebuild
+// from scratch every DefaultFullRebuildInterval. Call Start to begin the
+// background rebuild loop.
+func NewService(repo repositories.RevocationRepository, keyManager *keys.Manager, capacity int, falsePositiveRate float64, interval time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		repo:                repo,
+		keyManager:          keyManager,
+		logger:              logger,
+		interval:            interval,
+		fullRebuildInterval: DefaultFullRebuildInterval,
+		capacity:            capacity,
+		falsePositiveRate:   falsePositiveRate,
+		filter:              newBloomFilter(capacity, falsePositiveRate),
+		stopCh:              make(chan struct{}),
+	}
+}
+
+// Start launches the background rebuild loop, including one immediate
+// rebuild so Current() doesn't 404 until the first tick elapses.
+func (s *Service) Start() {
+	s.rebuild(context.Background())
+
+	s.wg.Add(1)
+	go s.run()
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.rebuild(context.Background())
+		}
+	}
+}
+
+// rebuild folds any tokens revoked since the last rebuild into the Bloom
+// filter and, if anything changed, signs a new digest version. When
+// nothing new was revoked it leaves the cached digest (and its ETag)
+// untouched rather than bumping the version for no reason.
+//
+// Every fullRebuildInterval it instead does a full rebuild: it re-fetches
+// the complete current revoked-non-expired set (sinceSeq reset to 0) and
+// starts a fresh filter rather than folding onto the old one. Without
+// that, a token added by an earlier incremental fold never leaves the
+// filter once it expires and drops out of RevokedTokenIDsSince's result,
+// so the filter's false-positive rate would only ever climb, eventually
+// toward 1, over a long-running process.
+func (s *Service) rebuild(ctx context.Context) {
+	s.mu.RLock()
+	sinceSeq := s.lastSeq
+	fullRebuild := time.Since(s.lastFullRebuild) >= s.fullRebuildInterval
+	s.mu.RUnlock()
+
+	if fullRebuild {
+		sinceSeq = 0
+	}
+
+	ids, maxSeq, err := s.repo.RevokedTokenIDsSince(ctx, sinceSeq)
+	if err != nil {
+		s.logger.Error("failed to fetch revoked token ids for digest rebuild",
+			"error", err,
+			"full_rebuild", fullRebuild,
+			"method", "revocationdigest.Service.rebuild")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(ids) == 0 && s.digest != nil && !fullRebuild {
+		return
+	}
+
+	if fullRebuild {
+		s.filter = newBloomFilter(s.capacity, s.falsePositiveRate)
+		s.lastFullRebuild = time.Now()
+	}
+
+	for _, id := range ids {
+		s.filter.Add(id)
+	}
+	s.lastSeq = maxSeq
+
+	version := int64(1)
+	if s.digest != nil {
+		version = s.digest.Version + 1
+	}
+	builtAt := time.Now()
+
+	kp := s.keyManager.Current()
+	rsaKey, ok := kp.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		s.logger.Error("revocation digest signing requires an RSA signing key",
+			"alg", kp.Alg,
+			"method", "revocationdigest.Service.rebuild")
+		return
+	}
+
+	signature, err := signDigest(rsaKey, version, s.filter.m, s.filter.k, builtAt, s.filter.bits)
+	if err != nil {
+		s.logger.Error("failed to sign revocation digest",
+			"error", err,
+			"method", "revocationdigest.Service.rebuild")
+		return
+	}
+
+	s.digest = &models.RevocationDigest{
+		Version:   version,
+		Bits:      append([]byte(nil), s.filter.bits...),
+		NumBits:   s.filter.m,
+		NumHashes: s.filter.k,
+		BuiltAt:   builtAt,
+		KeyID:     kp.KID,
+		Signature: signature,
+	}
+
+	s.logger.Info("rebuilt revocation digest",
+		"version", version,
+		"new_revocations", len(ids),
+		"full_rebuild", fullRebuild,
+		"last_seq", s.lastSeq)
+}
+
+// Current returns the most recently built digest. It errors if called
+// before the first rebuild completes.
+func (s *Service) Current() (*models.RevocationDigest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.digest == nil {
+		return nil, fmt.Errorf("revocation digest not yet built")
+	}
+	return s.digest, nil
+}
+
+// Shutdown stops the background rebuild loop, waiting up to ctx's
+// deadline for the current rebuild (if any) to finish.
+func (s *Service) Shutdown(ctx context.Context) error {
+	close(s.stopCh)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// signDigest signs the fields that identify this exact digest version
+// with RSASSA-PKCS1-v1_5/SHA-256, the same signing key used for JWTs.
+func signDigest(key *rsa.PrivateKey, version int64, numBits, numHashes uint64, builtAt time.Time, bits []byte) ([]byte, error) {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(version, 10)))
+	h.Write([]byte(strconv.FormatUint(numBits, 10)))
+	h.Write([]byte(strconv.FormatUint(numHashes, 10)))
+	h.Write([]byte(builtAt.UTC().Format(time.RFC3339Nano)))
+	h.Write(bits)
+
+	return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h.Sum(nil))
+}
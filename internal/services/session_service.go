@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+	"github.com/eupneart/auth-service/internal/services/sessions"
+	"github.com/google/uuid"
+)
+
+type sessionService struct {
+	sessionRepo repositories.SessionRepository
+	tokenStore  repositories.TokenStore
+	liveStore   *sessions.Store // optional; nil disables the in-memory cache
+	sessionTTL  time.Duration
+	logger      *slog.Logger
+}
+
+// NewSessionService creates a SessionService that records one session per
+// (user, device) on login and enforces TokenPreferences.MaxActiveSessions
+// by evicting the oldest session once the limit is exceeded. liveStore may
+// be nil, in which case sessions are tracked in the database only.
+func NewSessionService(sessionRepo repositories.SessionRepository, tokenStore repositories.TokenStore, liveStore *sessions.Store, sessionTTL time.Duration, logger *slog.Logger) SessionService {
+	return &sessionService{
+		sessionRepo: sessionRepo,
+		tokenStore:  tokenStore,
+		liveStore:   liveStore,
+		sessionTTL:  sessionTTL,
+		logger:      logger,
+	}
+}
+
+// RecordSession stores a new active session for userID/deviceID, evicting
+// the oldest session (and revoking its refresh token) if maxActiveSessions
+// is positive and already reached. A maxActiveSessions of 0 means unlimited.
+func (s *sessionService) RecordSession(ctx context.Context, userID int64, deviceID, refreshTokenID, userAgent, ipAddress string, maxActiveSessions int) error {
+	if maxActiveSessions > 0 {
+		count, err := s.sessionRepo.CountForUser(ctx, userID)
+		if err != nil {
+			s.logger.Error("failed to count active sessions for user",
+				"error", err,
+				"user_id", userID,
+				"method", "sessionService.RecordSession")
+			return fmt.Errorf("counting active sessions: %w", err)
+		}
+
+		for count >= int64(maxActiveSessions) {
+			oldest, err := s.sessionRepo.GetOldestForUser(ctx, userID)
+			if err != nil {
+				break
+			}
+
+			if err := s.tokenStore.RevokeToken(ctx, oldest.RefreshTokenID); err != nil {
+				s.logger.Warn("failed to revoke refresh token for evicted session",
+					"error", err,
+					"session_id", oldest.ID,
+					"user_id", userID,
+					"method", "sessionService.RecordSession")
+			}
+
+			if err := s.sessionRepo.DeleteByID(ctx, oldest.ID); err != nil {
+				s.logger.Warn("failed to delete evicted session",
+					"error", err,
+					"session_id", oldest.ID,
+					"user_id", userID,
+					"method", "sessionService.RecordSession")
+				break
+			}
+			if s.liveStore != nil {
+				s.liveStore.Forget(oldest.ID)
+			}
+
+			s.logger.Info("evicted oldest session to enforce max active sessions",
+				"session_id", oldest.ID,
+				"user_id", userID,
+				"max_active_sessions", maxActiveSessions)
+
+			count--
+		}
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		DeviceID:       deviceID,
+		RefreshTokenID: refreshTokenID,
+		UserAgent:      userAgent,
+		IPAddress:      ipAddress,
+		CreatedAt:      now,
+		LastSeenAt:     now,
+		ExpiresAt:      now.Add(s.sessionTTL),
+	}
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		s.logger.Error("failed to record session",
+			"error", err,
+			"user_id", userID,
+			"method", "sessionService.RecordSession")
+		return fmt.Errorf("recording session: %w", err)
+	}
+
+	if s.liveStore != nil {
+		s.liveStore.Track(*session)
+	}
+
+	return nil
+}
+
+// ListSessions returns every active session for userID.
+func (s *sessionService) ListSessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.GetAllForUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list sessions for user",
+			"error", err,
+			"user_id", userID,
+			"method", "sessionService.ListSessions")
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends a single session owned by userID, revoking its refresh token.
+func (s *sessionService) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found")
+	}
+	if session.UserID != userID {
+		return fmt.Errorf("session not found")
+	}
+
+	if err := s.tokenStore.RevokeToken(ctx, session.RefreshTokenID); err != nil {
+		s.logger.Warn("failed to revoke refresh token for session",
+			"error", err,
+			"session_id", sessionID,
+			"user_id", userID,
+			"method", "sessionService.RevokeSession")
+	}
+
+	if err := s.sessionRepo.DeleteByID(ctx, sessionID); err != nil {
+		s.logger.Error("failed to delete revoked session",
+			"error", err,
+			"session_id", sessionID,
+			"user_id", userID,
+			"method", "sessionService.RevokeSession")
+		return fmt.Errorf("revoking session: %w", err)
+	}
+
+	if s.liveStore != nil {
+		s.liveStore.Forget(sessionID)
+	}
+
+	s.logger.Info("revoked session",
+		"session_id", sessionID,
+		"user_id", userID)
+
+	return nil
+}
+
+// RevokeAllExcept ends every session for userID other than the one on
+// currentDeviceID, used by "log out other devices".
+func (s *sessionService) RevokeAllExcept(ctx context.Context, userID int64, currentDeviceID string) error {
+	sessions, err := s.sessionRepo.GetAllForUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("listing sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if session.DeviceID == currentDeviceID {
+			continue
+		}
+		if err := s.tokenStore.RevokeToken(ctx, session.RefreshTokenID); err != nil {
+			s.logger.Warn("failed to revoke refresh token while revoking other sessions",
+				"error", err,
+				"session_id", session.ID,
+				"user_id", userID,
+				"method", "sessionService.RevokeAllExcept")
+		}
+		if s.liveStore != nil {
+			s.liveStore.Forget(session.ID)
+		}
+	}
+
+	if err := s.sessionRepo.DeleteAllForUserExcept(ctx, userID, currentDeviceID); err != nil {
+		s.logger.Error("failed to delete other sessions",
+			"error", err,
+			"user_id", userID,
+			"method", "sessionService.RevokeAllExcept")
+		return fmt.Errorf("revoking other sessions: %w", err)
+	}
+
+	s.logger.Info("revoked all other sessions",
+		"user_id", userID)
+
+	return nil
+}
+
+// RevokeAll ends every session for userID, e.g. after a password reset.
+func (s *sessionService) RevokeAll(ctx context.Context, userID int64) error {
+	sessions, err := s.sessionRepo.GetAllForUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("listing sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if err := s.tokenStore.RevokeToken(ctx, session.RefreshTokenID); err != nil {
+			s.logger.Warn("failed to revoke refresh token while revoking all sessions",
+				"error", err,
+				"session_id", session.ID,
+				"user_id", userID,
+				"method", "sessionService.RevokeAll")
+		}
+	}
+
+	if s.liveStore != nil {
+		s.liveStore.EvictUser(userID)
+	}
+
+	if err := s.sessionRepo.DeleteAllForUser(ctx, userID); err != nil {
+		s.logger.Error("failed to delete all sessions",
+			"error", err,
+			"user_id", userID,
+			"method", "sessionService.RevokeAll")
+		return fmt.Errorf("revoking all sessions: %w", err)
+	}
+
+	s.logger.Info("revoked all sessions", "user_id", userID)
+
+	return nil
+}
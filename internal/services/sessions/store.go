@@ -0,0 +1,201 @@
+// Package sessions holds the live session set in memory, so checking or
+// touching a session doesn't cost a round-trip on every authenticated
+// request. It's a cache in front of repositories.SessionRepository, not a
+// replacement for it: a background reaper evicts expired sessions and
+// periodically flushes last-seen timestamps, and Shutdown flushes
+// everything still dirty before the process exits.
+package sessions
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+)
+
+type entry struct {
+	session models.Session
+	dirty   bool
+}
+
+// Store is the in-memory live-session cache. It implements
+// repositories.SessionNotifier so a TokenStore can push revocations and
+// activity into it directly instead of waiting for the next reap.
+type Store struct {
+	repo   repositories.SessionRepository
+	logger *slog.Logger
+
+	mu             sync.Mutex
+	sessions       map[string]*entry
+	byRefreshToken map[string]string // refresh token ID -> session ID
+
+	flushInterval time.Duration
+	reapInterval  time.Duration
+	stopCh        chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewStore creates a Store backed by repo. Call Start to begin the
+// background reaper/flush loop.
+func NewStore(repo repositories.SessionRepository, logger *slog.Logger) *Store {
+	return &Store{
+		repo:           repo,
+		logger:         logger,
+		sessions:       make(map[string]*entry),
+		byRefreshToken: make(map[string]string),
+		flushInterval:  1 * time.Minute,
+		reapInterval:   5 * time.Minute,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// Track adds a freshly created session to the live cache. Callers are
+// expected to have already persisted it via repositories.SessionRepository.
+func (s *Store) Track(session models.Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = &entry{session: session}
+	if session.RefreshTokenID != "" {
+		s.byRefreshToken[session.RefreshTokenID] = session.ID
+	}
+}
+
+// Forget drops a session from the live cache, e.g. once it's been deleted
+// from the database by RevokeSession/RevokeAllExcept/RevokeAll.
+func (s *Store) Forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forgetLocked(sessionID)
+}
+
+func (s *Store) forgetLocked(sessionID string) {
+	e, ok := s.sessions[sessionID]
+	if !ok {
+		return
+	}
+	delete(s.byRefreshToken, e.session.RefreshTokenID)
+	delete(s.sessions, sessionID)
+}
+
+// EvictUser drops every live session belonging to userID. It implements
+// repositories.SessionNotifier so TokenRepo.RevokeAllTokensForUser can call
+// it directly rather than waiting for the next reap.
+func (s *Store) EvictUser(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, e := range s.sessions {
+		if e.session.UserID == userID {
+			s.forgetLocked(id)
+		}
+	}
+}
+
+// TouchRefreshToken updates the in-memory last-seen time for whichever
+// session owns refreshTokenID. It implements repositories.SessionNotifier
+// so TokenRepo.UpdateLastUsed keeps live session state current without a
+// write on every request; the new timestamp is flushed to the database on
+// the next flush tick or on Shutdown.
+func (s *Store) TouchRefreshToken(refreshTokenID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessionID, ok := s.byRefreshToken[refreshTokenID]
+	if !ok {
+		return
+	}
+	e, ok := s.sessions[sessionID]
+	if !ok {
+		return
+	}
+	e.session.LastSeenAt = time.Now()
+	e.dirty = true
+}
+
+// Start launches the background reaper/flush loop. It returns immediately;
+// call Shutdown to stop it and flush any remaining dirty state.
+func (s *Store) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+func (s *Store) run() {
+	defer s.wg.Done()
+
+	reapTicker := time.NewTicker(s.reapInterval)
+	defer reapTicker.Stop()
+	flushTicker := time.NewTicker(s.flushInterval)
+	defer flushTicker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-reapTicker.C:
+			s.reapExpired(context.Background())
+		case <-flushTicker.C:
+			s.flushDirty(context.Background())
+		}
+	}
+}
+
+// reapExpired evicts (from memory only) sessions whose ExpiresAt has
+// passed; the database row itself is left in place (CleanupExpiredTokens
+// removes only token_metadata rows, not sessions).
+func (s *Store) reapExpired(ctx context.Context) {
+	now := time.Now()
+
+	s.mu.Lock()
+	var expired []string
+	for id, e := range s.sessions {
+		if !e.session.ExpiresAt.IsZero() && e.session.ExpiresAt.Before(now) {
+			expired = append(expired, id)
+		}
+	}
+	for _, id := range expired {
+		s.forgetLocked(id)
+	}
+	s.mu.Unlock()
+
+	if len(expired) > 0 {
+		s.logger.Info("reaped expired sessions from live cache", "count", len(expired))
+	}
+}
+
+// flushDirty persists last-seen timestamps for sessions touched since the
+// previous flush.
+func (s *Store) flushDirty(ctx context.Context) {
+	s.mu.Lock()
+	var toFlush []models.Session
+	for _, e := range s.sessions {
+		if e.dirty {
+			toFlush = append(toFlush, e.session)
+			e.dirty = false
+		}
+	}
+	s.mu.Unlock()
+
+	for _, session := range toFlush {
+		if err := s.repo.UpdateLastSeen(ctx, session.ID, session.LastSeenAt); err != nil {
+			s.logger.Warn("failed to flush session last seen",
+				"error", err,
+				"session_id", session.ID,
+				"method", "sessions.Store.flushDirty")
+		}
+	}
+}
+
+// Shutdown stops the background loop and flushes any still-dirty session
+// state, so a clean SIGTERM doesn't lose last-seen updates that hadn't hit
+// their next flush tick yet.
+func (s *Store) Shutdown(ctx context.Context) error {
+	close(s.stopCh)
+	s.wg.Wait()
+
+	s.flushDirty(ctx)
+	return nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/repositories"
+)
+
+type tokenAdminService struct {
+	repo   repositories.TokenAdminRepository
+	logger *slog.Logger
+}
+
+// NewTokenAdminService creates a TokenAdminService.
+func NewTokenAdminService(repo repositories.TokenAdminRepository, logger *slog.Logger) TokenAdminService {
+	return &tokenAdminService{repo: repo, logger: logger}
+}
+
+// ListTokens returns one page of tokens matching filter.
+func (s *tokenAdminService) ListTokens(ctx context.Context, filter models.TokenFilter, cursor string, limit int) ([]models.TokenMetadata, string, error) {
+	tokens, nextCursor, err := s.repo.ListTokens(ctx, filter, cursor, limit)
+	if err != nil {
+		s.logger.Error("failed to list tokens",
+			"error", err,
+			"method", "tokenAdminService.ListTokens")
+		return nil, "", fmt.Errorf("listing tokens: %w", err)
+	}
+	return tokens, nextCursor, nil
+}
+
+// StreamTokens returns a channel of every token matching filter, for
+// admin exports too large to page through by hand.
+func (s *tokenAdminService) StreamTokens(ctx context.Context, filter models.TokenFilter) (<-chan models.TokenMetadata, error) {
+	tokens, err := s.repo.StreamTokens(ctx, filter)
+	if err != nil {
+		s.logger.Error("failed to start token stream",
+			"error", err,
+			"method", "tokenAdminService.StreamTokens")
+		return nil, fmt.Errorf("starting token stream: %w", err)
+	}
+	return tokens, nil
+}
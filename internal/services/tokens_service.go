@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/eupneart/auth-service/internal/keys"
 	"github.com/eupneart/auth-service/internal/models"
 	"github.com/eupneart/auth-service/internal/repositories"
 	"github.com/golang-jwt/jwt/v5"
@@ -14,29 +15,158 @@ import (
 )
 
 type TokenServiceConfig struct {
-	JWTSecret            string
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
 	Issuer               string
 }
 
 type tokenService struct {
-	config   TokenServiceConfig
-	userRepo repositories.UserRepoInterface
-	store    repositories.TokenStore
-	logger   *slog.Logger
+	config     TokenServiceConfig
+	userRepo   repositories.UserRepoInterface
+	store      repositories.TokenStore
+	keyManager *keys.Manager
+	logger     *slog.Logger
+
+	// mfaService is optional: when set, GenerateTokens checks it and
+	// issues an mfa_challenge token instead of a normal pair for users
+	// with MFA enabled. Deployments that don't use MFA leave it nil and
+	// GenerateTokens behaves exactly as before.
+	mfaService MFAService
 }
 
-func NewTokenService(config TokenServiceConfig, userRepo repositories.UserRepoInterface, store repositories.TokenStore, logger *slog.Logger) TokenService {
+// NewTokenService creates a TokenService that signs access and refresh
+// tokens with keyManager's current signing key (RS256 or ES256, whichever
+// algorithm that key uses), embedding its
+// kid in the token header so resource servers can pick the right key out
+// of the JWKS endpoint. mfaService may be nil if MFA isn't in use.
+func NewTokenService(config TokenServiceConfig, userRepo repositories.UserRepoInterface, store repositories.TokenStore, keyManager *keys.Manager, mfaService MFAService, logger *slog.Logger) TokenService {
 	return &tokenService{
-		config:   config,
-		userRepo: userRepo,
-		store:    store,
-		logger:   logger,
+		config:     config,
+		userRepo:   userRepo,
+		store:      store,
+		keyManager: keyManager,
+		mfaService: mfaService,
+		logger:     logger,
 	}
 }
 
-func (s *tokenService) GenerateTokens(ctx context.Context, user *models.User) (accessToken, refreshToken string, err error) {
+// sign signs claims with the key manager's current key - RS256 or ES256,
+// whichever the manager was configured to generate - and stamps the
+// resulting JWT header with that key's kid.
+func (s *tokenService) sign(claims *models.Claims) (string, error) {
+	kp := s.keyManager.Current()
+	if kp == nil {
+		return "", ErrSigningKeyNotFound
+	}
+
+	method, err := signingMethodFor(kp.Alg)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kp.KID
+
+	return token.SignedString(kp.PrivateKey)
+}
+
+// signingMethodFor maps a keys.Algorithm to its jwt.SigningMethod.
+func signingMethodFor(alg keys.Algorithm) (jwt.SigningMethod, error) {
+	switch alg {
+	case keys.AlgES256:
+		return jwt.SigningMethodES256, nil
+	case keys.AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+}
+
+func (s *tokenService) GenerateTokens(ctx context.Context, user *models.User, deviceID string) (accessToken, refreshToken string, err error) {
+	if s.mfaService != nil {
+		enabled, mfaErr := s.mfaService.IsEnabled(ctx, user.ID)
+		if mfaErr != nil {
+			s.logger.Error("Failed to check mfa enrollment",
+				slog.Int64("user_id", user.ID),
+				slog.String("error", mfaErr.Error()))
+			return "", "", fmt.Errorf("failed to check mfa enrollment: %w", mfaErr)
+		}
+		if enabled {
+			challengeToken, chErr := s.issueMFAChallenge(ctx, user, deviceID)
+			return challengeToken, "", chErr
+		}
+	}
+
+	return s.issueTokenPair(ctx, user, deviceID, []string{"pwd"}, 1)
+}
+
+// issueMFAChallenge signs a short-lived mfa_challenge token in place of a
+// normal token pair, for a user who has MFA enabled. It carries no AMR of
+// its own - it only proves the password step passed - and is good for
+// nothing but MFAService-backed verification at /mfa/verify.
+func (s *tokenService) issueMFAChallenge(ctx context.Context, user *models.User, deviceID string) (string, error) {
+	challengeID := uuid.New().String()
+	now := time.Now()
+
+	claims := &models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(models.DefaultMFAChallengeLifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.config.Issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			ID:        challengeID,
+		},
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenType: models.TokenTypeMFAChallenge,
+		DeviceID:  deviceID,
+		AMR:       []string{},
+	}
+
+	token, err := s.sign(claims)
+	if err != nil {
+		s.logger.Error("Failed to generate mfa challenge token",
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return "", fmt.Errorf("failed to generate mfa challenge token: %w", err)
+	}
+
+	metadata := &models.TokenMetadata{
+		ID:        challengeID,
+		UserID:    user.ID,
+		TokenType: models.TokenTypeMFAChallenge,
+		IsRevoked: false,
+		CreatedAt: now,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if err := s.store.SaveTokenMetadata(ctx, metadata); err != nil {
+		s.logger.Error("Failed to store mfa challenge metadata",
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return "", fmt.Errorf("failed to store mfa challenge metadata: %w", err)
+	}
+
+	s.logger.Info("Issued mfa challenge token",
+		slog.Int64("user_id", user.ID),
+		slog.String("challenge_token_id", challengeID))
+
+	return token, nil
+}
+
+// CompleteMFAChallenge issues the real access+refresh pair for a user who
+// has just passed MFAService.Verify or VerifyRecoveryCode against their
+// mfa_challenge token. The resulting tokens carry amr=["pwd","otp"] and
+// aal=2, distinguishing them from a plain password-only login.
+func (s *tokenService) CompleteMFAChallenge(ctx context.Context, user *models.User, deviceID string) (accessToken, refreshToken string, err error) {
+	return s.issueTokenPair(ctx, user, deviceID, []string{"pwd", "otp"}, 2)
+}
+
+// issueTokenPair signs and stores a fresh access+refresh pair for user,
+// stamping amr/aal onto both. GenerateTokens uses this directly for a
+// password-only login; CompleteMFAChallenge uses it once a second factor
+// has also been verified.
+func (s *tokenService) issueTokenPair(ctx context.Context, user *models.User, deviceID string, amr []string, aal int) (accessToken, refreshToken string, err error) {
 	s.logger.Info("Generating tokens for user",
 		slog.Int64("user_id", user.ID),
 		slog.String("email", user.Email))
@@ -44,6 +174,7 @@ func (s *tokenService) GenerateTokens(ctx context.Context, user *models.User) (a
 	// Generate unique IDs for both tokens
 	accessTokenID := uuid.New().String()
 	refreshTokenID := uuid.New().String()
+	authTime := jwt.NewNumericDate(time.Now())
 
 	// Create access token claims
 	accessClaims := &models.Claims{
@@ -59,6 +190,10 @@ func (s *tokenService) GenerateTokens(ctx context.Context, user *models.User) (a
 		Email:     user.Email,
 		Role:      user.Role,
 		TokenType: models.TokenTypeAccess,
+		DeviceID:  deviceID,
+		AuthTime:  authTime,
+		AMR:       amr,
+		AAL:       aal,
 	}
 
 	// Create refresh token claims
@@ -74,11 +209,14 @@ func (s *tokenService) GenerateTokens(ctx context.Context, user *models.User) (a
 		UserID:    user.ID,
 		Email:     user.Email,
 		TokenType: models.TokenTypeRefresh,
+		DeviceID:  deviceID,
+		AuthTime:  authTime,
+		AMR:       amr,
+		AAL:       aal,
 	}
 
 	// Generate access token
-	accessTokenJWT := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = accessTokenJWT.SignedString([]byte(s.config.JWTSecret))
+	accessToken, err = s.sign(accessClaims)
 	if err != nil {
 		s.logger.Error("Failed to generate access token",
 			slog.Int64("user_id", user.ID),
@@ -87,8 +225,7 @@ func (s *tokenService) GenerateTokens(ctx context.Context, user *models.User) (a
 	}
 
 	// Generate refresh token
-	refreshTokenJWT := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err = refreshTokenJWT.SignedString([]byte(s.config.JWTSecret))
+	refreshToken, err = s.sign(refreshClaims)
 	if err != nil {
 		s.logger.Error("Failed to generate refresh token",
 			slog.Int64("user_id", user.ID),
@@ -113,6 +250,10 @@ func (s *tokenService) GenerateTokens(ctx context.Context, user *models.User) (a
 		IsRevoked: false,
 		CreatedAt: time.Now(),
 		ExpiresAt: refreshClaims.ExpiresAt.Time,
+		// FamilyID is its own jti here, since this is the root of a new
+		// rotation lineage; RotateRefreshToken propagates it unchanged to
+		// every descendant.
+		FamilyID: refreshTokenID,
 	}
 
 	// Store both tokens metadata
@@ -143,13 +284,7 @@ func (s *tokenService) GenerateTokens(ctx context.Context, user *models.User) (a
 // ValidateToken verifies a token and returns its claims if valid
 func (s *tokenService) ValidateToken(ctx context.Context, tokenStr string) (*models.Claims, error) {
 	// Parse and validate token
-	token, err := jwt.ParseWithClaims(tokenStr, &models.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenStr, &models.Claims{}, s.keyFunc)
 	if err != nil {
 		s.logger.Warn("Failed to parse token", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -236,11 +371,11 @@ func (s *tokenService) RefreshAccessToken(ctx context.Context, refreshToken stri
 		Email:     user.Email,
 		Role:      user.Role,
 		TokenType: models.TokenTypeAccess,
+		AuthTime:  claims.AuthTime,
 	}
 
 	// Generate access token
-	accessTokenJWT := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = accessTokenJWT.SignedString([]byte(s.config.JWTSecret))
+	accessToken, err = s.sign(accessClaims)
 	if err != nil {
 		s.logger.Error("Failed to generate new access token",
 			slog.Int64("user_id", user.ID),
@@ -274,6 +409,129 @@ func (s *tokenService) RefreshAccessToken(ctx context.Context, refreshToken stri
 	return accessToken, nil
 }
 
+// Reauthenticate issues a fresh, short-lived access token stamped with
+// AuthTime reset to now, for step-up flows that re-verify the caller's
+// password (see AuthHandler.Reauthenticate) without rotating their refresh
+// token, touching SessionService, or otherwise disturbing the session
+// they're already in.
+func (s *tokenService) Reauthenticate(ctx context.Context, user *models.User, deviceID string) (accessToken string, err error) {
+	accessTokenID := uuid.New().String()
+	now := time.Now()
+
+	accessClaims := &models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.config.Issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			ID:        accessTokenID,
+		},
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: models.TokenTypeAccess,
+		DeviceID:  deviceID,
+		AuthTime:  jwt.NewNumericDate(now),
+	}
+
+	accessToken, err = s.sign(accessClaims)
+	if err != nil {
+		s.logger.Error("Failed to generate access token during reauthentication",
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	accessMetadata := &models.TokenMetadata{
+		ID:        accessTokenID,
+		UserID:    user.ID,
+		TokenType: models.TokenTypeAccess,
+		IsRevoked: false,
+		CreatedAt: now,
+		ExpiresAt: accessClaims.ExpiresAt.Time,
+	}
+
+	if err := s.store.SaveTokenMetadata(ctx, accessMetadata); err != nil {
+		s.logger.Error("Failed to store reauthentication access token metadata",
+			slog.String("token_id", accessTokenID),
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return "", fmt.Errorf("failed to store access token metadata: %w", err)
+	}
+
+	s.logger.Info("Successfully reauthenticated",
+		slog.Int64("user_id", user.ID),
+		slog.String("new_token_id", accessTokenID))
+
+	return accessToken, nil
+}
+
+// Introspect implements RFC 7662 token introspection for resource
+// servers: any failure to parse, validate, or look up tokenStr collapses
+// to {Active: false} rather than an error, so a caller asking whether a
+// token is good can't learn why it isn't.
+func (s *tokenService) Introspect(ctx context.Context, tokenStr string) (*models.IntrospectionResponse, error) {
+	claims, err := s.ValidateToken(ctx, tokenStr)
+	if err != nil {
+		return &models.IntrospectionResponse{Active: false}, nil
+	}
+
+	resp := &models.IntrospectionResponse{
+		Active:    true,
+		Sub:       strconv.FormatInt(claims.UserID, 10),
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+		TokenType: claims.TokenType,
+		Role:      claims.Role,
+		SessionID: claims.DeviceID,
+	}
+	if claims.ExpiresAt != nil {
+		resp.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.Iat = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		resp.Nbf = claims.NotBefore.Unix()
+	}
+
+	if metadata, err := s.store.GetTokenMetadata(ctx, claims.ID); err == nil && metadata != nil {
+		resp.FamilyID = metadata.FamilyID
+	}
+
+	return resp, nil
+}
+
+// RevokeTokenCascade revokes tokenStr; when it's a refresh token it also
+// revokes every token descended from the same family (see
+// RotateRefreshToken), so a resource server that catches a compromised
+// refresh token can shut down the whole chain in one call instead of
+// only the token it happened to see.
+func (s *tokenService) RevokeTokenCascade(ctx context.Context, tokenStr string) error {
+	claims, err := s.parseTokenWithoutValidation(tokenStr)
+	if err != nil {
+		s.logger.Error("Failed to parse token for cascading revocation", slog.String("error", err.Error()))
+		return fmt.Errorf("failed to parse token for revocation: %w", err)
+	}
+
+	if claims.TokenType != models.TokenTypeRefresh {
+		return s.store.RevokeToken(repositories.WithActor(ctx, &claims.UserID, ""), claims.ID)
+	}
+
+	familyID := claims.ID
+	if metadata, err := s.store.GetTokenMetadata(ctx, claims.ID); err == nil && metadata != nil && metadata.FamilyID != "" {
+		familyID = metadata.FamilyID
+	}
+
+	s.logger.Info("Revoking token family via resource server cascade",
+		slog.String("token_id", claims.ID),
+		slog.String("family_id", familyID),
+		slog.Int64("user_id", claims.UserID))
+
+	return s.store.RevokeTokenFamily(repositories.WithActor(ctx, &claims.UserID, ""), familyID)
+}
+
 // RevokeToken invalidates a token (for blacklisting)
 func (s *tokenService) RevokeToken(ctx context.Context, tokenStr string) error {
 	// Parse token to get ID
@@ -289,7 +547,7 @@ func (s *tokenService) RevokeToken(ctx context.Context, tokenStr string) error {
 		slog.String("token_type", claims.TokenType))
 
 	// Revoke token in store
-	if err := s.store.RevokeToken(ctx, claims.ID); err != nil {
+	if err := s.store.RevokeToken(repositories.WithActor(ctx, &claims.UserID, ""), claims.ID); err != nil {
 		s.logger.Error("Failed to revoke token",
 			slog.String("token_id", claims.ID),
 			slog.String("error", err.Error()))
@@ -303,6 +561,23 @@ func (s *tokenService) RevokeToken(ctx context.Context, tokenStr string) error {
 	return nil
 }
 
+// RevokeTokenByID blacklists a token by its jti directly, without needing
+// the signed token string, e.g. to revoke an access token whose claims a
+// caller already has from a validated request.
+func (s *tokenService) RevokeTokenByID(ctx context.Context, tokenID string) error {
+	s.logger.Info("Revoking token by ID", slog.String("token_id", tokenID))
+
+	if err := s.store.RevokeTokenByID(ctx, tokenID); err != nil {
+		s.logger.Error("Failed to revoke token by ID",
+			slog.String("token_id", tokenID),
+			slog.String("error", err.Error()))
+		return err
+	}
+
+	s.logger.Info("Successfully revoked token by ID", slog.String("token_id", tokenID))
+	return nil
+}
+
 // GetTokenMetadata retrieves stored metadata for a token
 func (s *tokenService) GetTokenMetadata(ctx context.Context, tokenID string) (*models.TokenMetadata, error) {
 	metadata, err := s.store.GetTokenMetadata(ctx, tokenID)
@@ -343,6 +618,188 @@ func (s *tokenService) RevokeAllTokensForUser(ctx context.Context, userID string
 	return nil
 }
 
+// GetAllTokensForUser returns every token recorded for a user, active or not.
+func (s *tokenService) GetAllTokensForUser(ctx context.Context, userID string) ([]models.TokenMetadata, error) {
+	tokens, err := s.store.GetAllTokensForUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get all tokens for user",
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RotateRefreshToken exchanges refreshToken for a new access+refresh pair.
+// If refreshToken was already rotated once, presenting it again means it
+// was stolen: its whole token family (see models.TokenMetadata.FamilyID)
+// is revoked and ErrRefreshTokenReused is returned instead of a new pair.
+func (s *tokenService) RotateRefreshToken(ctx context.Context, refreshToken string) (accessToken, newRefreshToken string, err error) {
+	claims, err := s.ValidateToken(ctx, refreshToken)
+	if err != nil {
+		s.logger.Warn("Invalid refresh token provided for rotation", slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("invalid refresh token: %w", err)
+	}
+
+	if claims.TokenType != models.TokenTypeRefresh {
+		s.logger.Warn("Attempted to rotate with non-refresh token",
+			slog.String("token_type", claims.TokenType),
+			slog.Int64("user_id", claims.UserID))
+		return "", "", ErrInvalidTokenType
+	}
+
+	metadata, err := s.store.GetTokenMetadata(ctx, claims.ID)
+	if err != nil {
+		s.logger.Error("Failed to load refresh token metadata for rotation",
+			slog.String("token_id", claims.ID),
+			slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("failed to load refresh token metadata: %w", err)
+	}
+
+	if metadata.RotatedAt != nil {
+		familyID := metadata.FamilyID
+		if familyID == "" {
+			familyID = metadata.ID
+		}
+
+		s.logger.Warn("refresh token reuse detected, revoking token family",
+			slog.String("token_id", claims.ID),
+			slog.String("family_id", familyID),
+			slog.Int64("user_id", claims.UserID))
+
+		auditCtx := repositories.WithActor(ctx, &claims.UserID, "")
+		if revokeErr := s.store.RevokeTokenFamily(auditCtx, familyID); revokeErr != nil {
+			s.logger.Error("Failed to revoke token family after refresh token reuse",
+				slog.Int64("user_id", claims.UserID),
+				slog.String("family_id", familyID),
+				slog.String("error", revokeErr.Error()))
+		}
+
+		return "", "", ErrRefreshTokenReused
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get user for refresh token rotation",
+			slog.Int64("user_id", claims.UserID),
+			slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := time.Now()
+	metadata.RotatedAt = &now
+	if err := s.store.SaveTokenMetadata(ctx, metadata); err != nil {
+		s.logger.Error("Failed to mark refresh token rotated",
+			slog.String("token_id", claims.ID),
+			slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("failed to mark refresh token rotated: %w", err)
+	}
+
+	accessTokenID := uuid.New().String()
+	newRefreshTokenID := uuid.New().String()
+
+	accessClaims := &models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.config.Issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			ID:        accessTokenID,
+		},
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: models.TokenTypeAccess,
+		DeviceID:  claims.DeviceID,
+		AuthTime:  claims.AuthTime,
+	}
+
+	newRefreshClaims := &models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.RefreshTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.config.Issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			ID:        newRefreshTokenID,
+		},
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenType: models.TokenTypeRefresh,
+		DeviceID:  claims.DeviceID,
+		AuthTime:  claims.AuthTime,
+	}
+
+	accessToken, err = s.sign(accessClaims)
+	if err != nil {
+		s.logger.Error("Failed to generate access token during rotation",
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	newRefreshToken, err = s.sign(newRefreshClaims)
+	if err != nil {
+		s.logger.Error("Failed to generate refresh token during rotation",
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	accessMetadata := &models.TokenMetadata{
+		ID:            accessTokenID,
+		UserID:        user.ID,
+		TokenType:     models.TokenTypeAccess,
+		IsRevoked:     false,
+		CreatedAt:     now,
+		ExpiresAt:     accessClaims.ExpiresAt.Time,
+		ParentTokenID: claims.ID,
+	}
+
+	// familyID carries forward from the token being rotated; fall back to
+	// its own jti for tokens issued before FamilyID existed, so they still
+	// get a family to anchor reuse detection to.
+	familyID := metadata.FamilyID
+	if familyID == "" {
+		familyID = metadata.ID
+	}
+
+	newRefreshMetadata := &models.TokenMetadata{
+		ID:            newRefreshTokenID,
+		UserID:        user.ID,
+		TokenType:     models.TokenTypeRefresh,
+		IsRevoked:     false,
+		CreatedAt:     now,
+		ExpiresAt:     newRefreshClaims.ExpiresAt.Time,
+		ParentTokenID: claims.ID,
+		FamilyID:      familyID,
+	}
+
+	if err := s.store.SaveTokenMetadata(ctx, accessMetadata); err != nil {
+		s.logger.Error("Failed to store access token metadata during rotation",
+			slog.String("token_id", accessTokenID),
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("failed to store access token metadata: %w", err)
+	}
+
+	if err := s.store.SaveTokenMetadata(ctx, newRefreshMetadata); err != nil {
+		s.logger.Error("Failed to store refresh token metadata during rotation",
+			slog.String("token_id", newRefreshTokenID),
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return "", "", fmt.Errorf("failed to store refresh token metadata: %w", err)
+	}
+
+	s.logger.Info("Successfully rotated refresh token",
+		slog.Int64("user_id", user.ID),
+		slog.String("old_refresh_token_id", claims.ID),
+		slog.String("new_refresh_token_id", newRefreshTokenID))
+
+	return accessToken, newRefreshToken, nil
+}
+
 // CleanupExpiredTokens removes expired tokens from storage
 func (s *tokenService) CleanupExpiredTokens(ctx context.Context) error {
 	s.logger.Info("Starting cleanup of expired tokens")
@@ -359,9 +816,7 @@ func (s *tokenService) CleanupExpiredTokens(ctx context.Context) error {
 
 // Helper function to parse token without validation
 func (s *tokenService) parseTokenWithoutValidation(tokenString string) (*models.Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &models.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.config.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &models.Claims{}, s.keyFunc)
 
 	if err != nil && token == nil {
 		return nil, err
@@ -374,3 +829,28 @@ func (s *tokenService) parseTokenWithoutValidation(tokenString string) (*models.
 
 	return claims, nil
 }
+
+// keyFunc resolves the public key to verify a token against, selecting it
+// by the kid stamped in the token header so rotated-out keys still verify
+// during their overlap window. It accepts either RS256 or ES256 tokens,
+// since a previous key (from before a JWT_SIGNING_ALG change) may still be
+// inside its overlap window even after the manager switches algorithms.
+func (s *tokenService) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, ErrSigningKeyNotFound
+	}
+
+	kp, ok := s.keyManager.Lookup(kid)
+	if !ok {
+		return nil, ErrSigningKeyNotFound
+	}
+
+	return kp.PrivateKey.Public(), nil
+}
@@ -2,182 +2,222 @@ package services
 
 import (
 	"context"
-	"errors"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"log/slog"
 	"time"
 
+	appmiddleware "github.com/eupneart/auth-service/internal/api/middleware"
 	"github.com/eupneart/auth-service/internal/models"
 	"github.com/eupneart/auth-service/internal/repositories"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// dummyPasswordHash is a bcrypt hash of a random value nobody will ever
+// type in. Authenticate compares against it whenever the email doesn't
+// match a user, so a lookup miss still pays for a bcrypt comparison
+// instead of returning early - otherwise the missing-account and
+// wrong-password cases would be distinguishable by response time. The
+// hash is at cost 12, so the two paths cost the same only while real
+// passwords are bcrypt at that cost (the default); with a different
+// BCRYPT_COST or with Argon2id the costs differ.
+const dummyPasswordHash = "$2a$12$B2p1p1hV4bZhE8vYwL0KUeYVYQYV1KxqgV9gk6i1kH1ZqkHqxkqzS"
+
 type UserService struct {
 	userRepo repositories.UserRepoInterface
+
+	// Hasher hashes new passwords and verifies stored ones. Required
+	// (New always sets it) so that swapping algorithms - e.g. bcrypt to
+	// Argon2id - is a one-line change at the call site rather than a
+	// UserService-wide rewrite.
+	Hasher PasswordHasher
+
+	// SessionService is optional: when set, ResetPassword uses it to log
+	// the user out of every active session. It is assigned after
+	// construction rather than threaded through New so existing callers
+	// (and tests) that only need user CRUD are unaffected.
+	SessionService SessionService
+
+	// IdentityRepo is optional: when set, LinkIdentity records and looks up
+	// (provider, subject) -> user links for OIDC social login, instead of
+	// matching purely on email.
+	IdentityRepo repositories.IdentityRepository
 }
 
 const dbTimeout = 3 * time.Second
 
-// New is the function used to create an instance of the service package. 
+// New is the function used to create an instance of the service package.
 // It returns the type UserService.
-func New(userRepo repositories.UserRepoInterface) *UserService {
-	return &UserService{userRepo: userRepo}
+func New(userRepo repositories.UserRepoInterface, hasher PasswordHasher) *UserService {
+	return &UserService{userRepo: userRepo, Hasher: hasher}
 }
 
 func (s *UserService) GetAll(ctx context.Context) ([]*models.User, error) {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	
+
 	users, err := s.userRepo.GetAll(ctx)
 	if err != nil {
-		slog.Error("failed to get all users from repository",
+		logger.Error("failed to get all users from repository",
 			"error", err,
 			"method", "UserService.GetAll")
 		return nil, err
 	}
-	
-	slog.Info("successfully retrieved all users",
+
+	logger.Info("successfully retrieved all users",
 		"user_count", len(users),
 		"method", "UserService.GetAll")
-	
+
 	return users, nil
 }
 
 func (s *UserService) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	// Validate the input user data
 	if id == 0 {
-		slog.Warn("invalid user ID provided (zero value)",
+		logger.Warn("invalid user ID provided (zero value)",
 			"id", id,
 			"method", "UserService.GetByID")
 		return nil, fmt.Errorf("user ID must be provided")
 	}
-	
+
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	
+
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
-		slog.Error("failed to get user by ID from repository",
+		logger.Error("failed to get user by ID from repository",
 			"error", err,
 			"id", id,
 			"method", "UserService.GetByID")
 		return nil, err
 	}
-	
-	slog.Info("successfully retrieved user by ID",
+
+	logger.Info("successfully retrieved user by ID",
 		"id", id,
 		"email", user.Email,
 		"method", "UserService.GetByID")
-	
+
 	return user, nil
 }
 
 func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	if email == "" {
-		slog.Warn("empty email provided",
+		logger.Warn("empty email provided",
 			"email", email,
 			"method", "UserService.GetByEmail")
 		return nil, fmt.Errorf("user email must be provided")
 	}
-	
+
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	
+
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		slog.Error("failed to get user by email from repository",
+		logger.Error("failed to get user by email from repository",
 			"error", err,
 			"email", email,
 			"method", "UserService.GetByEmail")
 		return nil, err
 	}
-	
-	slog.Info("successfully retrieved user by email",
+
+	logger.Info("successfully retrieved user by email",
 		"email", email,
 		"user_id", user.ID,
 		"method", "UserService.GetByEmail")
-	
+
 	return user, nil
 }
 
 // Update updates the fields of a user. Only non-zero or non-empty fields in the user struct will be updated.
 func (s *UserService) Update(ctx context.Context, u models.User) error {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	if u.ID == 0 {
-		slog.Warn("invalid user ID provided for update (zero value)",
+		logger.Warn("invalid user ID provided for update (zero value)",
 			"id", u.ID,
 			"method", "UserService.Update")
 		return fmt.Errorf("user ID must be provided")
 	}
-	
+
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	
+
 	// Call the repository's Update method
-	err := s.userRepo.Update(ctx, u)
+	err := s.userRepo.Update(s.withAuditActor(ctx), u)
 	if err != nil {
-		slog.Error("failed to update user in repository",
+		logger.Error("failed to update user in repository",
 			"error", err,
 			"user_id", u.ID,
 			"email", u.Email,
 			"method", "UserService.Update")
 		return fmt.Errorf("failed to update user: %w", err)
 	}
-	
-	slog.Info("successfully updated user",
+
+	logger.Info("successfully updated user",
 		"user_id", u.ID,
 		"email", u.Email,
 		"method", "UserService.Update")
-	
+
 	return nil
 }
 
 func (s *UserService) DeleteByID(ctx context.Context, id int64) error {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	if id == 0 {
-		slog.Warn("invalid user ID provided for deletion (zero value)",
+		logger.Warn("invalid user ID provided for deletion (zero value)",
 			"id", id,
 			"method", "UserService.DeleteByID")
 		return fmt.Errorf("user ID must be provided")
 	}
-	
+
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	
-	err := s.userRepo.DeleteByID(ctx, id)
+
+	err := s.userRepo.DeleteByID(s.withAuditActor(ctx), id)
 	if err != nil {
-		slog.Error("failed to delete user from repository",
+		logger.Error("failed to delete user from repository",
 			"error", err,
 			"id", id,
 			"method", "UserService.DeleteByID")
 		return err
 	}
-	
-	slog.Info("successfully deleted user",
+
+	logger.Info("successfully deleted user",
 		"id", id,
 		"method", "UserService.DeleteByID")
-	
+
 	return nil
 }
 
 func (s *UserService) Insert(ctx context.Context, u models.User) (int64, error) {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	
+
 	// Encrypt the user pwd (hash the pwd)
-	encryptedPwd, err := bcrypt.GenerateFromPassword([]byte(u.Password), 12)
+	encryptedPwd, err := s.Hasher.Hash(u.Password)
 	if err != nil {
-		slog.Error("failed to encrypt password",
+		logger.Error("failed to encrypt password",
 			"error", err,
 			"email", u.Email,
 			"method", "UserService.Insert")
 		return 0, fmt.Errorf("encrypting password: %w", err)
 	}
-	
+
 	// Update the user password
-	u.Password = string(encryptedPwd)
-	
-	newUserID, err := s.userRepo.Insert(ctx, u)
+	u.Password = encryptedPwd
+
+	newUserID, err := s.userRepo.Insert(s.withAuditActor(ctx), u)
 	if err != nil {
-		slog.Error("failed to insert user in repository",
+		logger.Error("failed to insert user in repository",
 			"error", err,
 			"email", u.Email,
 			"first_name", u.FirstName,
@@ -185,82 +225,307 @@ func (s *UserService) Insert(ctx context.Context, u models.User) (int64, error)
 			"method", "UserService.Insert")
 		return 0, err
 	}
-	
-	slog.Info("successfully inserted new user",
+
+	logger.Info("successfully inserted new user",
 		"user_id", newUserID,
 		"email", u.Email,
 		"first_name", u.FirstName,
 		"last_name", u.LastName,
 		"method", "UserService.Insert")
-	
+
 	return newUserID, nil
 }
 
 // ResetPassword is the method used to change a user's password.
 func (s *UserService) ResetPassword(ctx context.Context, user *models.User) error {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
-	
+
 	// Hash the new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), 12)
+	hashedPassword, err := s.Hasher.Hash(user.Password)
 	if err != nil {
-		slog.Error("failed to hash password during reset",
+		logger.Error("failed to hash password during reset",
 			"error", err,
 			"user_id", user.ID,
 			"method", "UserService.ResetPassword")
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
-	
-	// Create a user struct with the new password
-	u := models.User{
-		ID:       user.ID,                // Specify the user ID
-		Password: string(hashedPassword), // Update the password field
-	}
-	
-	err = s.userRepo.Update(ctx, u)
+
+	// UpdatePassword, not the generic Update: a reset only ever touches
+	// the password column, and Update's field list would also overwrite
+	// is_active with this partial struct's zero value, deactivating the
+	// user on every reset.
+	err = s.userRepo.UpdatePassword(s.withAuditActor(ctx), user.ID, hashedPassword)
 	if err != nil {
-		slog.Error("failed to update password in repository",
+		logger.Error("failed to update password in repository",
 			"error", err,
 			"user_id", user.ID,
 			"method", "UserService.ResetPassword")
 		return err
 	}
-	
-	slog.Info("successfully reset user password",
+
+	// A password reset invalidates every existing session, not just the
+	// credentials, so a stolen refresh token can't outlive the reset.
+	if s.SessionService != nil {
+		if err := s.SessionService.RevokeAll(ctx, user.ID); err != nil {
+			logger.Warn("failed to revoke sessions after password reset",
+				"error", err,
+				"user_id", user.ID,
+				"method", "UserService.ResetPassword")
+		}
+	}
+
+	logger.Info("successfully reset user password",
 		"user_id", user.ID,
 		"method", "UserService.ResetPassword")
-	
+
 	return nil
 }
 
-// PasswordMatches uses Go's bcrypt package to compare a user supplied password
-// with the hash we have stored for a given user in the database. If the password
-// and hash match, we return true; otherwise, we return false.
-func (s *UserService) PasswordMatches(u *models.User, plainText string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(plainText))
+// PasswordMatches compares a user supplied password with the hash stored
+// for them, via Hasher. If the password and hash match, we return true;
+// otherwise, we return false.
+//
+// On a match, it also opportunistically rehashes the stored password if
+// Hasher reports it needs one - e.g. a legacy bcrypt hash once Hasher is
+// Argon2idHasher, or a bcrypt hash at a since-raised cost. This lets
+// changing Hasher's algorithm or parameters roll out to existing users as
+// they log in, rather than requiring a bulk rehash migration. A failed
+// compare never triggers a rewrite, and a rewrite failure is logged but
+// doesn't fail the login.
+func (s *UserService) PasswordMatches(ctx context.Context, u *models.User, plainText string) (bool, error) {
+	ok, needsRehash, err := s.Hasher.Verify(u.Password, plainText)
 	if err != nil {
-		switch {
-		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword): // invalid password
-			slog.Warn("password mismatch during authentication",
-				"user_id", u.ID,
-				"email", u.Email,
-				"method", "UserService.PasswordMatches")
-			return false, nil
-		default:
-			slog.Error("unexpected error during password comparison",
-				"error", err,
-				"user_id", u.ID,
-				"email", u.Email,
-				"method", "UserService.PasswordMatches")
-			return false, fmt.Errorf("Error comparing password for user ID %d: %v", u.ID, err)
-		}
+		return false, fmt.Errorf("Error comparing password for user ID %d: %v", u.ID, err)
 	}
-	
-	// Passwords match
-	slog.Info("password validation successful",
-		"user_id", u.ID,
-		"email", u.Email,
-		"method", "UserService.PasswordMatches")
-	
+	if !ok {
+		return false, nil
+	}
+
+	if needsRehash {
+		s.rehashPassword(ctx, u, plainText)
+	}
+
 	return true, nil
 }
+
+// rehashPassword replaces u's stored password with a fresh Hasher.Hash
+// result. Called only after a successful PasswordMatches comparison, so
+// the plaintext is known to be correct.
+func (s *UserService) rehashPassword(ctx context.Context, u *models.User, plainText string) {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	newHash, err := s.Hasher.Hash(plainText)
+	if err != nil {
+		logger.Warn("failed to rehash password",
+			"error", err,
+			"user_id", u.ID,
+			"method", "UserService.rehashPassword")
+		return
+	}
+
+	// UpdatePassword, not Update: same reason as ResetPassword above -
+	// this partial User has everything but Password at its zero value,
+	// and Update would write is_active=false along with the new hash.
+	if err := s.userRepo.UpdatePassword(s.withAuditActor(ctx), u.ID, newHash); err != nil {
+		logger.Warn("failed to persist rehashed password",
+			"error", err,
+			"user_id", u.ID,
+			"method", "UserService.rehashPassword")
+		return
+	}
+
+	logger.Info("rehashed stored password",
+		"user_id", u.ID,
+		"algorithm", s.Hasher.Identifier(),
+		"method", "UserService.rehashPassword")
+}
+
+// Authenticate looks up email and verifies plainText against its stored
+// password, returning ErrInvalidCredentials for both an unknown email and
+// a wrong password - callers must not branch on which occurred, so a
+// caller probing for valid usernames can't tell them apart from the
+// response. Handlers should call this instead of GetByEmail+PasswordMatches
+// separately.
+func (s *UserService) Authenticate(ctx context.Context, email, plainText string) (*models.User, error) {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		// Burn the same bcrypt comparison a real user would cost, so this
+		// path doesn't return measurably faster than a wrong-password one.
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(plainText))
+		logger.Warn("authentication attempt for unknown email",
+			"email", email,
+			"method", "UserService.Authenticate")
+		return nil, ErrInvalidCredentials
+	}
+
+	valid, err := s.PasswordMatches(ctx, user, plainText)
+	if err != nil {
+		logger.Error("error checking password during authentication",
+			"error", err,
+			"user_id", user.ID,
+			"method", "UserService.Authenticate")
+		return nil, ErrInvalidCredentials
+	}
+	if !valid {
+		logger.Warn("invalid password attempt",
+			"user_id", user.ID,
+			"method", "UserService.Authenticate")
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// LinkExternalIdentity provisions a local user for an identity asserted by
+// an external connector (OIDC, LDAP, GitHub, ...), or returns the existing
+// user already linked to that email. The externalID is accepted for
+// logging/auditing; matching is done on email since the User model has no
+// dedicated external-identity table yet. emailVerified must be true for
+// auto-linking to an existing account to be allowed - otherwise a
+// connector that can be coaxed into asserting an arbitrary unverified
+// email could take over any local account with a matching address.
+// Provisioning a brand-new user is unaffected: it only ever creates an
+// account, never takes over one.
+func (s *UserService) LinkExternalIdentity(ctx context.Context, provider, externalID, email string, emailVerified bool, firstName, lastName string) (*models.User, error) {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	if email == "" {
+		logger.Warn("external identity login without an email claim",
+			"provider", provider,
+			"external_id", externalID,
+			"method", "UserService.LinkExternalIdentity")
+		return nil, fmt.Errorf("external identity from %s did not include an email", provider)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil && existing != nil {
+		if !emailVerified {
+			logger.Warn("refusing to auto-link external identity to existing account: email not verified",
+				"provider", provider,
+				"external_id", externalID,
+				"user_id", existing.ID,
+				"method", "UserService.LinkExternalIdentity")
+			return nil, fmt.Errorf("cannot link %s identity: email %q is not verified", provider, email)
+		}
+		logger.Info("linked external identity to existing user",
+			"provider", provider,
+			"external_id", externalID,
+			"user_id", existing.ID,
+			"method", "UserService.LinkExternalIdentity")
+		return existing, nil
+	}
+
+	// No local user yet: provision one with a random password, since the
+	// account will only ever authenticate through the external connector.
+	randomPassword, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("generating placeholder password: %w", err)
+	}
+
+	newUser := models.User{
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     email,
+		Password:  randomPassword,
+		Role:      "user",
+		IsActive:  true,
+	}
+
+	newUserID, err := s.Insert(ctx, newUser)
+	if err != nil {
+		logger.Error("failed to provision user for external identity",
+			"error", err,
+			"provider", provider,
+			"external_id", externalID,
+			"method", "UserService.LinkExternalIdentity")
+		return nil, fmt.Errorf("provisioning user for %s identity: %w", provider, err)
+	}
+
+	logger.Info("provisioned new user from external identity",
+		"provider", provider,
+		"external_id", externalID,
+		"user_id", newUserID,
+		"method", "UserService.LinkExternalIdentity")
+
+	return s.userRepo.GetByID(ctx, newUserID)
+}
+
+// LinkIdentity provisions or links a local user for an OIDC social login,
+// keyed on (provider, subject) rather than email so a later email change
+// upstream doesn't sever the link. If no identity exists yet and
+// allowSignup is false, the login is rejected instead of provisioning a
+// new account. emailVerified is forwarded to LinkExternalIdentity, which
+// refuses to auto-link to an existing account by email unless it's true.
+func (s *UserService) LinkIdentity(ctx context.Context, provider, subject, email string, emailVerified bool, firstName, lastName string, allowSignup bool) (*models.User, error) {
+	logger := appmiddleware.LoggerFromContext(ctx)
+
+	if s.IdentityRepo == nil {
+		return nil, fmt.Errorf("identity linking is not configured")
+	}
+
+	identity, err := s.IdentityRepo.GetByProviderSubject(ctx, provider, subject)
+	if err == nil && identity != nil {
+		return s.userRepo.GetByID(ctx, identity.UserID)
+	}
+
+	if !allowSignup {
+		logger.Warn("oidc login rejected: no existing identity and signup disabled",
+			"provider", provider,
+			"method", "UserService.LinkIdentity")
+		return nil, fmt.Errorf("no account linked to this %s identity", provider)
+	}
+
+	user, err := s.LinkExternalIdentity(ctx, provider, subject, email, emailVerified, firstName, lastName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.IdentityRepo.Create(ctx, &models.Identity{
+		Provider:  provider,
+		Subject:   subject,
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		logger.Warn("failed to record new identity link",
+			"error", err,
+			"provider", provider,
+			"user_id", user.ID,
+			"method", "UserService.LinkIdentity")
+	}
+
+	return user, nil
+}
+
+// withAuditActor annotates ctx with the authenticated caller (if any) and
+// their remote IP, so AuditingUserRepo can attribute the mutation it's
+// about to perform on a user_audit_log row.
+func (s *UserService) withAuditActor(ctx context.Context) context.Context {
+	var actorID *int64
+	if claims := appmiddleware.ClaimsFromContext(ctx); claims != nil {
+		actorID = &claims.UserID
+	}
+
+	return repositories.WithActor(ctx, actorID, appmiddleware.RemoteIPFromContext(ctx))
+}
+
+// randomToken returns a cryptographically random, hex-encoded token of n
+// random bytes, used as an unusable placeholder password for externally
+// authenticated accounts.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updatePasswordCall records one stubUserRepo.UpdatePassword invocation.
+type updatePasswordCall struct {
+	userID       int64
+	passwordHash string
+}
+
+// stubUserRepo is a minimal repositories.UserRepoInterface double that
+// records the last Update call and every UpdatePassword call, so tests
+// can assert what actually got persisted instead of only that the call
+// returned no error.
+type stubUserRepo struct {
+	lastUpdate          models.User
+	updatePasswordCalls []updatePasswordCall
+}
+
+func (r *stubUserRepo) GetAll(ctx context.Context) ([]*models.User, error) { return nil, nil }
+func (r *stubUserRepo) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	return nil, nil
+}
+func (r *stubUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return nil, nil
+}
+func (r *stubUserRepo) Update(ctx context.Context, u models.User) error {
+	r.lastUpdate = u
+	return nil
+}
+func (r *stubUserRepo) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	r.updatePasswordCalls = append(r.updatePasswordCalls, updatePasswordCall{userID: userID, passwordHash: passwordHash})
+	return nil
+}
+func (r *stubUserRepo) DeleteByID(ctx context.Context, id int64) error { return nil }
+func (r *stubUserRepo) Insert(ctx context.Context, u models.User) (int64, error) {
+	return 0, nil
+}
+
+// staleHasher always reports a match that needsRehash, simulating a
+// bcrypt cost upgrade or a legacy algorithm being replaced.
+type staleHasher struct {
+	freshHash string
+}
+
+func (h *staleHasher) Hash(plaintext string) (string, error) { return h.freshHash, nil }
+func (h *staleHasher) Verify(hash, plaintext string) (bool, bool, error) {
+	return true, true, nil
+}
+func (h *staleHasher) Identifier() string { return "stub" }
+
+// TestUserService_PasswordMatches_PersistsRehash guards against a
+// regression where rehashPassword's call to userRepo.Update silently
+// dropped the new password hash (Update's field list didn't include
+// password), so a successful bcrypt-cost-upgrade or algorithm-migration
+// rehash was computed and then thrown away on every login. It also
+// guards the follow-on regression where rehashPassword called the
+// generic Update with a partial User, which would silently reset
+// is_active to false on every rehash: rehashPassword must go through
+// UpdatePassword, which has no is_active column in its UPDATE at all, so
+// repo.lastUpdate (the generic-Update tracker) must stay untouched.
+func TestUserService_PasswordMatches_PersistsRehash(t *testing.T) {
+	repo := &stubUserRepo{}
+	hasher := &staleHasher{freshHash: "$argon2id$v=19$...freshly-hashed"}
+	service := New(repo, hasher)
+
+	user := &models.User{ID: 42, Password: "$2a$10$someOldBcryptHash"}
+
+	ok, err := service.PasswordMatches(context.Background(), user, "correct-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.Len(t, repo.updatePasswordCalls, 1)
+	assert.Equal(t, int64(42), repo.updatePasswordCalls[0].userID)
+	assert.Equal(t, hasher.freshHash, repo.updatePasswordCalls[0].passwordHash)
+	assert.Equal(t, models.User{}, repo.lastUpdate, "rehash must not go through the generic Update, which would also reset is_active")
+}
@@ -12,7 +12,7 @@ import (
 
 func TestUserService_GetAll(t *testing.T) {
 	mockRepo := new(MockUserRepo)
-	service := New(mockRepo)
+	service := New(mockRepo, NewBcryptHasher(0))
 
 	ctx := context.Background()
 	expectedUsers := []*models.User{
@@ -31,7 +31,7 @@ func TestUserService_GetAll(t *testing.T) {
 
 func TestUserService_GetById(t *testing.T) {
 	mockRepo := new(MockUserRepo)
-	service := New(mockRepo)
+	service := New(mockRepo, NewBcryptHasher(0))
 
 	ctx := context.Background()
 
@@ -52,7 +52,7 @@ func TestUserService_GetById(t *testing.T) {
 
 func TestUserService_GetByEmail(t *testing.T) {
 	mockRepo := new(MockUserRepo)
-	service := New(mockRepo)
+	service := New(mockRepo, NewBcryptHasher(0))
 
 	ctx := context.Background()
 
@@ -73,7 +73,7 @@ func TestUserService_GetByEmail(t *testing.T) {
 
 func TestUserService_Update(t *testing.T) {
 	mockRepo := new(MockUserRepo)
-	service := New(mockRepo)
+	service := New(mockRepo, NewBcryptHasher(0))
 
 	ctx := context.Background()
 
@@ -94,7 +94,7 @@ func TestUserService_Update(t *testing.T) {
 
 func TestUserService_DeleteByID(t *testing.T) {
 	mockRepo := new(MockUserRepo)
-	service := New(mockRepo)
+	service := New(mockRepo, NewBcryptHasher(0))
 
 	ctx := context.Background()
 
@@ -112,7 +112,7 @@ func TestUserService_DeleteByID(t *testing.T) {
 
 func TestUserService_Insert(t *testing.T) {
 	mockRepo := new(MockUserRepo)
-	service := New(mockRepo)
+	service := New(mockRepo, NewBcryptHasher(0))
 
 	ctx := context.Background()
 
@@ -139,7 +139,7 @@ func TestUserService_Insert(t *testing.T) {
 
 func TestUserService_ResetPassword(t *testing.T) {
 	mockRepo := new(MockUserRepo)
-	service := New(mockRepo)
+	service := New(mockRepo, NewBcryptHasher(0))
 
 	ctx := context.Background()
 
@@ -165,7 +165,9 @@ func TestUserService_ResetPassword(t *testing.T) {
 }
 
 func TestUserService_PasswordMatches(t *testing.T) {
-	service := New(nil) // No repo needed for this test
+	service := New(nil, NewBcryptHasher(0)) // No repo needed: NewBcryptHasher(0)'s default cost is below the hash's cost 12, so no rehash is attempted
+
+	ctx := context.Background()
 
 	// Generate a bcrypt hash of a known password
 	plainTextPassword := "testpassword"
@@ -202,7 +204,7 @@ func TestUserService_PasswordMatches(t *testing.T) {
 				Password: tc.storedPassword,
 			}
 
-			match, err := service.PasswordMatches(user, tc.inputPassword)
+			match, err := service.PasswordMatches(ctx, user, tc.inputPassword)
 
 			assert.Equal(t, tc.expectedMatch, match)
 			if tc.expectError {
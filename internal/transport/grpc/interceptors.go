@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/eupneart/auth-service/internal/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// rpcTimeout bounds how long a single unary RPC may run, mirroring the
+// dbTimeout pattern services.UserService applies to repository calls.
+const rpcTimeout = 3 * time.Second
+
+// unauthenticatedMethods lists the full gRPC method names that don't
+// require a bearer token, matching the REST API's public /authenticate
+// and /register routes.
+var unauthenticatedMethods = map[string]bool{
+	"/auth.v1.AuthService/Authenticate": true,
+	"/auth.v1.AuthService/Register":     true,
+}
+
+// AuthInterceptor validates the JWT bearer token carried in the
+// "authorization" metadata header for every RPC except those in
+// unauthenticatedMethods, using the same TokenService the HTTP API relies on.
+func AuthInterceptor(tokenService services.TokenService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if unauthenticatedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing metadata")
+		}
+
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "missing authorization header")
+		}
+
+		token := strings.TrimPrefix(values[0], "Bearer ")
+		if token == values[0] {
+			return nil, status.Error(codes.Unauthenticated, "authorization header must use the Bearer scheme")
+		}
+
+		claims, err := tokenService.ValidateToken(ctx, token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+
+		return handler(context.WithValue(ctx, claimsCtxKey{}, claims), req)
+	}
+}
+
+type claimsCtxKey struct{}
+
+// LoggingInterceptor logs the outcome of every RPC at the same level of
+// detail the chi RequestLogger middleware applies to HTTP requests.
+func LoggingInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		attrs := []any{
+			"method", info.FullMethod,
+			"duration", time.Since(start),
+		}
+		if err != nil {
+			logger.Error("grpc call failed", append(attrs, "error", err)...)
+		} else {
+			logger.Info("grpc call completed", attrs...)
+		}
+
+		return resp, err
+	}
+}
+
+// TimeoutInterceptor bounds every unary RPC to rpcTimeout, the same way
+// services.UserService bounds its repository calls to dbTimeout.
+func TimeoutInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,170 @@
+// Package grpc implements the internal gRPC transport for AuthService,
+// delegating every RPC to the same services.UserService and
+// services.TokenService used by the chi HTTP API. It exists so internal
+// callers can talk to auth without HTTP overhead; external clients keep
+// using the REST routes in internal/api.
+package grpc
+
+import (
+	"context"
+
+	authv1 "github.com/eupneart/auth-service/gen/auth/v1"
+	"github.com/eupneart/auth-service/internal/models"
+	"github.com/eupneart/auth-service/internal/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements authv1.AuthServiceServer on top of the existing
+// service layer.
+type Server struct {
+	authv1.UnimplementedAuthServiceServer
+
+	UserService  *services.UserService
+	TokenService services.TokenService
+}
+
+// NewServer builds a gRPC AuthService implementation backed by userService
+// and tokenService.
+func NewServer(userService *services.UserService, tokenService services.TokenService) *Server {
+	return &Server{
+		UserService:  userService,
+		TokenService: tokenService,
+	}
+}
+
+func (s *Server) Authenticate(ctx context.Context, req *authv1.AuthenticateRequest) (*authv1.AuthenticateResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	// Authenticate returns the same error for an unknown email and a wrong
+	// password, so this response can't be used to enumerate accounts.
+	user, err := s.UserService.Authenticate(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	if !user.IsActive {
+		return nil, status.Error(codes.PermissionDenied, "account is deactivated")
+	}
+
+	accessToken, refreshToken, err := s.TokenService.GenerateTokens(ctx, user, "")
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to generate authentication tokens")
+	}
+
+	return &authv1.AuthenticateResponse{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        models.DefaultTokenType,
+		ExpiresIn:        int64(models.DefaultAccessTokenLifetime.Seconds()),
+		RefreshExpiresIn: int64(models.DefaultRefreshTokenLifetime.Seconds()),
+	}, nil
+}
+
+func (s *Server) Register(ctx context.Context, req *authv1.RegisterRequest) (*authv1.RegisterResponse, error) {
+	existing, err := s.UserService.GetByEmail(ctx, req.GetEmail())
+	if err == nil && existing != nil {
+		return nil, status.Error(codes.AlreadyExists, "user with this email already exists")
+	}
+
+	usr := models.User{
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+		Email:     req.GetEmail(),
+		Password:  req.GetPassword(),
+		Role:      "user",
+		IsActive:  true,
+	}
+
+	newUserID, err := s.UserService.Insert(ctx, usr)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to create user account")
+	}
+
+	newUser, err := s.UserService.GetByID(ctx, newUserID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to complete user registration")
+	}
+
+	accessToken, refreshToken, err := s.TokenService.GenerateTokens(ctx, newUser, "")
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to generate authentication tokens")
+	}
+
+	return &authv1.RegisterResponse{
+		User:         toProtoUser(newUser),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *Server) GetByID(ctx context.Context, req *authv1.GetByIDRequest) (*authv1.User, error) {
+	user, err := s.UserService.GetByID(ctx, req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return toProtoUser(user), nil
+}
+
+func (s *Server) Update(ctx context.Context, req *authv1.UpdateRequest) (*authv1.UpdateResponse, error) {
+	pu := req.GetUser()
+	if pu == nil || pu.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID must be provided")
+	}
+
+	u := models.User{
+		ID:        pu.GetId(),
+		FirstName: pu.GetFirstName(),
+		LastName:  pu.GetLastName(),
+		Email:     pu.GetEmail(),
+		Role:      pu.GetRole(),
+		IsActive:  pu.GetIsActive(),
+	}
+
+	if err := s.UserService.Update(ctx, u); err != nil {
+		return nil, status.Error(codes.Internal, "failed to update user")
+	}
+
+	return &authv1.UpdateResponse{Ok: true}, nil
+}
+
+func (s *Server) DeleteByID(ctx context.Context, req *authv1.DeleteByIDRequest) (*authv1.DeleteByIDResponse, error) {
+	if err := s.UserService.DeleteByID(ctx, req.GetId()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to delete user")
+	}
+	return &authv1.DeleteByIDResponse{Ok: true}, nil
+}
+
+// ResetPassword delegates straight to UserService.ResetPassword, so it
+// inherits that method's UpdatePassword-only write path rather than the
+// generic Update - a partial User built from just the new password must
+// never flow through Update, which would also reset is_active.
+func (s *Server) ResetPassword(ctx context.Context, req *authv1.ResetPasswordRequest) (*authv1.ResetPasswordResponse, error) {
+	if req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "new_password is required")
+	}
+
+	if err := s.UserService.ResetPassword(ctx, &models.User{ID: req.GetUserId(), Password: req.GetNewPassword()}); err != nil {
+		return nil, status.Error(codes.Internal, "failed to reset password")
+	}
+
+	return &authv1.ResetPasswordResponse{Ok: true}, nil
+}
+
+func toProtoUser(u *models.User) *authv1.User {
+	pu := &authv1.User{
+		Id:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		Role:      u.Role,
+		IsActive:  u.IsActive,
+	}
+	if !u.LastLogin.IsZero() {
+		pu.LastLogin = timestamppb.New(u.LastLogin)
+	}
+	return pu
+}
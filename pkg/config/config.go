@@ -0,0 +1,246 @@
+// Package config is the Viper-backed replacement for the package-level
+// config globals historically scattered across this codebase:
+// internal/config.Config (still importing the pre-rename module path
+// github.com/eupneart/auth-service/env, so it's been dead and unreachable
+// from main since the eupneart rename) and pkg/env's getEnv/GetEnvAsInt
+// parsing straight off os.LookupEnv into a package-level *EnvConfig. Both
+// mutate (or mutated) a single package-level struct, which makes
+// constructing a second, differently-configured instance inside the same
+// process - most commonly from a parallel test - racy at best and
+// impossible at worst.
+//
+// Load layers configuration from, in increasing order of precedence:
+// built-in defaults, an optional YAML/TOML file, environment variables,
+// and explicit Options. It returns an *env.EnvConfig (aliased here as
+// Config) rather than a new parallel struct, so every existing consumer
+// of *env.EnvConfig keeps working unchanged; Load is a drop-in
+// replacement for env.LoadEnv() at the call site in cmd/auth-service.
+// Unlike env.LoadEnv, Load does not publish its result to the
+// package-level env.Config - the returned *Config is meant to be threaded
+// through main into the repositories/services that need it, the same way
+// cmd/auth-service already does for the fields it reads off cfg. It does
+// still install the process-wide env.Logger (via env.InitLogger), since
+// that's a singleton by design, the same way slog.SetDefault is - not a
+// per-configuration value that call sites could disagree about. Settings
+// that aren't part of EnvConfig at all (e.g. JWT_SIGNING_ALG, PASSWORD_*)
+// are still read ad hoc off the process environment at their call sites
+// in cmd/auth-service; only multiplying copies of *EnvConfig's own
+// loading logic was this package's job to fix.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/eupneart/auth-service/pkg/env"
+	"github.com/joho/godotenv"
+	"github.com/spf13/viper"
+)
+
+// Config is the loaded application configuration. It's an alias for
+// env.EnvConfig (not a new struct) so Load can be substituted for
+// env.LoadEnv() without requiring every existing *env.EnvConfig consumer
+// to change.
+type Config = env.EnvConfig
+
+// Option customizes a Load call after defaults, the config file, and
+// environment variables have all been applied.
+type Option func(*viper.Viper)
+
+// WithConfigFile forces Load to read path as the config file, instead of
+// discovering one from the --config flag or AUTH_CONFIG env var.
+func WithConfigFile(path string) Option {
+	return func(v *viper.Viper) {
+		v.SetConfigFile(path)
+	}
+}
+
+// WithOverride sets key to value with the highest precedence, above the
+// config file and environment variables. Intended for tests that need a
+// one-off value without setting a real env var or config file.
+func WithOverride(key string, value interface{}) Option {
+	return func(v *viper.Viper) {
+		v.Set(key, value)
+	}
+}
+
+// Load builds a *Config by layering, in increasing precedence: built-in
+// defaults, an optional YAML/TOML file (path from AUTH_CONFIG, or from
+// WithConfigFile), environment variables, and opts. It validates the
+// result and returns an aggregated error if required settings are
+// missing or out of range.
+func Load(opts ...Option) (*Config, error) {
+	// Best-effort: deployments that already rely on a .env file (per
+	// pkg/env.LoadEnv's convention) keep working, since this just pushes
+	// its values into the process environment that AutomaticEnv reads
+	// below. A missing .env file is not an error.
+	envFile := ".env"
+	if appEnv, exists := os.LookupEnv("APP_ENV"); exists {
+		envFile = fmt.Sprintf(".env.%s", appEnv)
+	}
+	_ = godotenv.Load(envFile)
+
+	v := viper.New()
+	setDefaults(v)
+
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if configFile := os.Getenv("AUTH_CONFIG"); configFile != "" {
+		v.SetConfigFile(configFile)
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if v.ConfigFileUsed() != "" {
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("reading config file %s: %w", v.ConfigFileUsed(), err)
+		}
+	}
+
+	cfg := fromViper(v)
+
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+
+	env.InitLogger(cfg.AppEnv)
+
+	return cfg, nil
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("db_host", "localhost")
+	v.SetDefault("db_port", "5432")
+	v.SetDefault("db_user", "postgres")
+	v.SetDefault("db_password", "")
+	v.SetDefault("db_name", "auth_db")
+	v.SetDefault("jwt_secret", "defaultsecret")
+	v.SetDefault("jwt_issuer", "eupneart-auth-service")
+	v.SetDefault("app_port", "8080")
+	v.SetDefault("app_env", "development")
+	v.SetDefault("connectors_enabled", "")
+	v.SetDefault("smtp_host", "")
+	v.SetDefault("smtp_port", "587")
+	v.SetDefault("smtp_username", "")
+	v.SetDefault("smtp_password", "")
+	v.SetDefault("smtp_from", "no-reply@eupneart.com")
+	v.SetDefault("app_base_url", "http://localhost:4200")
+	v.SetDefault("token_store", "postgres")
+	v.SetDefault("redis_addr", "localhost:6379")
+	v.SetDefault("redis_password", "")
+	v.SetDefault("redis_db", 0)
+	v.SetDefault("bolt_path", "./data/tokens.db")
+	v.SetDefault("resource_server_secrets", "")
+	v.SetDefault("bcrypt_cost", 12)
+	v.SetDefault("password_hash_algo", "bcrypt")
+}
+
+// fromViper reads every key Load knows about back out of v into a
+// *Config. It's written field-by-field, the same way pkg/env.LoadEnv
+// reads each key off getEnv individually, rather than v.Unmarshal, since
+// ConnectorsEnabled and ResourceServerSecrets need the same comma-split
+// handling env.LoadEnv already does. OIDCProviders is read straight off
+// env.LoadOIDCProviders rather than through v - see that function's doc
+// comment for the reason.
+func fromViper(v *viper.Viper) *Config {
+	return &env.EnvConfig{
+		DBHost:     v.GetString("db_host"),
+		DBPort:     v.GetString("db_port"),
+		DBUser:     v.GetString("db_user"),
+		DBPassword: v.GetString("db_password"),
+		DBName:     v.GetString("db_name"),
+		JWTSecret:  v.GetString("jwt_secret"),
+		JWTIssuer:  v.GetString("jwt_issuer"),
+		AppPort:    v.GetString("app_port"),
+		AppEnv:     v.GetString("app_env"),
+
+		ConnectorsEnabled: splitAndTrim(v.GetString("connectors_enabled")),
+
+		SMTPHost:     v.GetString("smtp_host"),
+		SMTPPort:     v.GetString("smtp_port"),
+		SMTPUsername: v.GetString("smtp_username"),
+		SMTPPassword: v.GetString("smtp_password"),
+		SMTPFrom:     v.GetString("smtp_from"),
+
+		AppBaseURL: v.GetString("app_base_url"),
+
+		OIDCProviders: env.LoadOIDCProviders(),
+
+		TokenStoreBackend: v.GetString("token_store"),
+		RedisAddr:         v.GetString("redis_addr"),
+		RedisPassword:     v.GetString("redis_password"),
+		RedisDB:           v.GetInt("redis_db"),
+		BoltPath:          v.GetString("bolt_path"),
+
+		ResourceServerSecrets: splitAndTrim(v.GetString("resource_server_secrets")),
+
+		BcryptCost:       v.GetInt("bcrypt_cost"),
+		PasswordHashAlgo: v.GetString("password_hash_algo"),
+	}
+}
+
+// splitAndTrim mirrors the unexported helper of the same name in
+// pkg/env: split a comma-separated value into trimmed, non-empty entries.
+func splitAndTrim(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if trimmed := strings.TrimSpace(p); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// ValidationErrors aggregates every problem Load's validation pass finds,
+// instead of returning only the first one.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validate checks invariants Load can't enforce via defaults alone. It
+// returns a ValidationErrors collecting every failure, or nil if cfg is
+// usable.
+func validate(cfg *Config) error {
+	var errs ValidationErrors
+
+	if cfg.AppEnv != "development" && cfg.JWTSecret == "defaultsecret" {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be set to a non-default value outside development (app_env=%s)", cfg.AppEnv))
+	}
+
+	if port, err := strconv.Atoi(cfg.AppPort); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("APP_PORT must be an integer between 1 and 65535, got %q", cfg.AppPort))
+	}
+
+	if port, err := strconv.Atoi(cfg.DBPort); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("DB_PORT must be an integer between 1 and 65535, got %q", cfg.DBPort))
+	}
+
+	if cfg.BcryptCost < 4 || cfg.BcryptCost > 31 {
+		errs = append(errs, fmt.Errorf("BCRYPT_COST must be between 4 and 31, got %d", cfg.BcryptCost))
+	}
+
+	if cfg.PasswordHashAlgo != "bcrypt" && cfg.PasswordHashAlgo != "argon2id" {
+		errs = append(errs, fmt.Errorf("PASSWORD_HASH_ALGO must be \"bcrypt\" or \"argon2id\", got %q", cfg.PasswordHashAlgo))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
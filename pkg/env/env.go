@@ -2,9 +2,10 @@ package env
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -20,10 +21,81 @@ type EnvConfig struct {
 	JWTIssuer  string
 	AppPort    string
 	AppEnv     string
+
+	// ConnectorsEnabled lists the external identity connectors to register,
+	// e.g. CONNECTORS_ENABLED=oidc,ldap. Per-connector settings are read
+	// separately from CONNECTOR_<NAME>_* variables.
+	ConnectorsEnabled []string
+
+	// SMTP settings for the forgot-password mailer. Username/Password may
+	// be empty for relays that don't require auth.
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// AppBaseURL is the public URL of the frontend, used to build links
+	// sent in emails (e.g. the password reset link).
+	AppBaseURL string
+
+	// OIDCProviders holds the settings for each OIDC social login provider
+	// enabled via OIDC_PROVIDERS, keyed by provider name.
+	OIDCProviders map[string]ProviderConfig
+
+	// TokenStoreBackend selects the TokenStore implementation: "postgres"
+	// (default), "redis", "bolt", or "memory", for deployments that need
+	// faster revocation checks than a Postgres round-trip per request
+	// allows, or that want to avoid a separate datastore entirely.
+	TokenStoreBackend string
+	RedisAddr         string
+	RedisPassword     string
+	RedisDB           int
+	BoltPath          string
+
+	// ResourceServerSecrets gates the RFC 7662/7009 endpoints mounted for
+	// downstream resource servers (see appmiddleware.RequireResourceServerAuth),
+	// one shared secret per trusted resource server, from
+	// RESOURCE_SERVER_SECRETS=secret1,secret2.
+	ResourceServerSecrets []string
+
+	// BcryptCost is the work factor services.BcryptHasher generates new
+	// hashes with. Stored hashes at a lower cost are transparently
+	// rehashed on the user's next successful login, so raising this value
+	// rolls out over time instead of requiring a one-off migration.
+	BcryptCost int
+
+	// PasswordHashAlgo selects the services.PasswordHasher UserService
+	// hashes and verifies passwords with: "bcrypt" (default) or
+	// "argon2id". Switching to "argon2id" doesn't invalidate existing
+	// bcrypt hashes - Argon2idHasher.Verify recognizes and accepts them,
+	// then rehashes them into Argon2id on that login.
+	PasswordHashAlgo string
+}
+
+// ProviderConfig is the per-provider configuration for OIDC social login,
+// read from OIDC_PROVIDER_<NAME>_* environment variables.
+type ProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	IssuerURL    string
+	RedirectURL  string
+
+	// AllowSignup controls whether a first-time login from this provider
+	// provisions a new local user, or is rejected when no account exists yet.
+	AllowSignup bool
+
+	// AllowedDomains restricts signin to email addresses in these domains.
+	// Empty means no restriction.
+	AllowedDomains []string
 }
 
 var Config *EnvConfig
 
+// Logger is the root structured logger, initialized by LoadEnv: a JSON
+// handler in production, a human-readable text handler everywhere else.
+var Logger *slog.Logger
+
 // Initialize AppConfig by loading environment variables
 func LoadEnv() *EnvConfig {
 	// Load .env file dynamically based on APP_ENV
@@ -32,9 +104,6 @@ func LoadEnv() *EnvConfig {
 		envFile = fmt.Sprintf(".env.%s", appEnv)
 	}
 	err := godotenv.Load(envFile)
-	if err != nil {
-		log.Printf("[INFO] No %s file found, using system environment variables", envFile)
-	}
 
 	Config = &EnvConfig{
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -46,13 +115,59 @@ func LoadEnv() *EnvConfig {
 		JWTIssuer:  getEnv("JWT_ISSUER", "eupneart-auth-service"),
 		AppPort:    getEnv("APP_PORT", "8080"),
 		AppEnv:     getEnv("APP_ENV", "development"),
+
+		ConnectorsEnabled: splitAndTrim(getEnv("CONNECTORS_ENABLED", "")),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "no-reply@eupneart.com"),
+
+		AppBaseURL: getEnv("APP_BASE_URL", "http://localhost:4200"),
+
+		OIDCProviders: LoadOIDCProviders(),
+
+		TokenStoreBackend: getEnv("TOKEN_STORE", "postgres"),
+		RedisAddr:         getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:     getEnv("REDIS_PASSWORD", ""),
+		RedisDB:           GetEnvAsInt("REDIS_DB", 0),
+		BoltPath:          getEnv("BOLT_PATH", "./data/tokens.db"),
+
+		ResourceServerSecrets: splitAndTrim(getEnv("RESOURCE_SERVER_SECRETS", "")),
+
+		BcryptCost:       GetEnvAsInt("BCRYPT_COST", 12),
+		PasswordHashAlgo: getEnv("PASSWORD_HASH_ALGO", "bcrypt"),
 	}
 
-  log.Print(Config)
+	InitLogger(Config.AppEnv)
+
+	if err != nil {
+		Logger.Info("no env file found, using system environment variables", slog.String("env_file", envFile))
+	}
+	Logger.Debug("configuration loaded", slog.String("app_env", Config.AppEnv))
 
 	return Config
 }
 
+// InitLogger builds the root slog.Logger for appEnv and installs it as the
+// process default: a JSON handler in production for log aggregation, a
+// text handler elsewhere for local readability. It takes appEnv
+// explicitly, rather than reading the package-level Config, so callers
+// that build their own *EnvConfig (pkg/config.Load) don't have to publish
+// it to the global first just to get a logger out of it.
+func InitLogger(appEnv string) {
+	var handler slog.Handler
+	if appEnv == "production" {
+		handler = slog.NewJSONHandler(os.Stdout, nil)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
+	}
+
+	Logger = slog.New(handler)
+	slog.SetDefault(Logger)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -60,12 +175,82 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// GetEnv gets an environment variable as a string with fallback
+func GetEnv(key, defaultValue string) string {
+	return getEnv(key, defaultValue)
+}
+
+// LoadOIDCProviders reads OIDC_PROVIDERS and builds a ProviderConfig for
+// each named provider from its OIDC_PROVIDER_<NAME>_* variables. It reads
+// directly from the process environment rather than through Viper, so
+// pkg/config.Load's config-file layer doesn't (yet) apply to it.
+func LoadOIDCProviders() map[string]ProviderConfig {
+	names := splitAndTrim(getEnv("OIDC_PROVIDERS", ""))
+	if len(names) == 0 {
+		return nil
+	}
+
+	providers := make(map[string]ProviderConfig, len(names))
+	for _, name := range names {
+		prefix := "OIDC_PROVIDER_" + strings.ToUpper(name) + "_"
+		providers[name] = ProviderConfig{
+			ClientID:       getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret:   getEnv(prefix+"CLIENT_SECRET", ""),
+			IssuerURL:      getEnv(prefix+"ISSUER_URL", ""),
+			RedirectURL:    getEnv(prefix+"REDIRECT_URL", ""),
+			AllowSignup:    getEnvAsBool(prefix+"ALLOW_SIGNUP", true),
+			AllowedDomains: splitAndTrim(getEnv(prefix+"ALLOWED_DOMAINS", "")),
+		}
+	}
+	return providers
+}
+
+// getEnvAsBool gets an environment variable as a bool with fallback.
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, strconv.FormatBool(defaultValue))
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// splitAndTrim splits a comma-separated env value into trimmed, non-empty
+// entries, e.g. "oidc, ldap" -> []string{"oidc", "ldap"}.
+func splitAndTrim(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if trimmed := strings.TrimSpace(p); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
 // GetEnvAsInt gets an environment variable as integer with fallback
 func GetEnvAsInt(key string, defaultValue int) int {
 	valueStr := getEnv(key, strconv.Itoa(defaultValue))
 	value, err := strconv.Atoi(valueStr)
 	if err != nil {
-		log.Printf("[WARN] Invalid integer for %s: %s, using default: %d", key, valueStr, defaultValue)
+		Logger.Warn("invalid integer env value, using default",
+			slog.String("key", key), slog.String("value", valueStr), slog.Int("default", defaultValue))
+		return defaultValue
+	}
+	return value
+}
+
+// GetEnvAsBool gets an environment variable as a boolean with fallback
+func GetEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, strconv.FormatBool(defaultValue))
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		Logger.Warn("invalid boolean env value, using default",
+			slog.String("key", key), slog.String("value", valueStr), slog.Bool("default", defaultValue))
 		return defaultValue
 	}
 	return value
@@ -76,20 +261,37 @@ func GetEnvAsDuration(key, defaultValue string) time.Duration {
 	valueStr := getEnv(key, defaultValue)
 	duration, err := time.ParseDuration(valueStr)
 	if err != nil {
-		log.Printf("[WARN] Invalid duration for %s: %s, using default: %s", key, valueStr, defaultValue)
+		Logger.Warn("invalid duration env value, using default",
+			slog.String("key", key), slog.String("value", valueStr), slog.String("default", defaultValue))
 		duration, _ = time.ParseDuration(defaultValue)
 	}
 	return duration
 }
 
-// IsProduction returns true if running in production environment
+// IsProduction returns true if the package-level Config, as populated by
+// LoadEnv, is running in production. Callers holding their own *EnvConfig
+// (e.g. from pkg/config.Load) should call its IsProduction method instead
+// of going through this global.
 func IsProduction() bool {
-	return Config.AppEnv == "production"
+	return Config.IsProduction()
 }
 
-// IsDevelopment returns true if running in development environment
+// IsDevelopment returns true if the package-level Config, as populated by
+// LoadEnv, is running in development. Callers holding their own *EnvConfig
+// (e.g. from pkg/config.Load) should call its IsDevelopment method instead
+// of going through this global.
 func IsDevelopment() bool {
-	return Config.AppEnv == "development"
+	return Config.IsDevelopment()
+}
+
+// IsProduction returns true if c.AppEnv is "production".
+func (c *EnvConfig) IsProduction() bool {
+	return c.AppEnv == "production"
+}
+
+// IsDevelopment returns true if c.AppEnv is "development".
+func (c *EnvConfig) IsDevelopment() bool {
+	return c.AppEnv == "development"
 }
 
 func (c *EnvConfig) ToDSN() string {
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"net/mail"
 	"regexp"
 	"strings"
@@ -38,11 +39,47 @@ func IsValidEmail(email string) bool {
 	return true
 }
 
-// IsValidPassword validates password strength with comprehensive rules
-func IsValidPassword(password string) bool {
-	// Basic length check
-	if len(password) < 8 || len(password) > 128 {
-		return false
+// PasswordPolicy describes the rules a password must satisfy. The zero
+// value accepts every password, even an empty one (no minimum, a MaxLength
+// of 0 means no maximum, and no character class is required), so always
+// start from DefaultPasswordPolicy rather than building one by hand.
+type PasswordPolicy struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireNumber  bool
+	RequireSpecial bool
+}
+
+// DefaultPasswordPolicy is the policy enforced before it became
+// configurable: 8-128 characters, with at least one upper-case,
+// lower-case, numeric, and special character.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      8,
+		MaxLength:      128,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireNumber:  true,
+		RequireSpecial: true,
+	}
+}
+
+// ActivePasswordPolicy is the policy ValidateRegistrationInput enforces.
+// main wires it up from PASSWORD_* environment variables at startup;
+// code that never touches it (including tests) keeps today's behavior
+// since it defaults to DefaultPasswordPolicy.
+var ActivePasswordPolicy = DefaultPasswordPolicy()
+
+// Validate reports the first rule password fails to satisfy, or nil if
+// it satisfies every rule in p.
+func (p PasswordPolicy) Validate(password string) error {
+	if len(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return fmt.Errorf("password must be at most %d characters", p.MaxLength)
 	}
 
 	var (
@@ -66,8 +103,27 @@ func IsValidPassword(password string) bool {
 		}
 	}
 
-	// All criteria must be met
-	return hasUpper && hasLower && hasNumber && hasSpecial
+	if p.RequireUpper && !hasUpper {
+		return errors.New("password must contain at least one uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		return errors.New("password must contain at least one lowercase letter")
+	}
+	if p.RequireNumber && !hasNumber {
+		return errors.New("password must contain at least one number")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		return errors.New("password must contain at least one special character")
+	}
+
+	return nil
+}
+
+// IsValidPassword reports whether password satisfies ActivePasswordPolicy.
+// Kept for callers that only need a bool; ValidateRegistrationInput calls
+// Validate directly so it can surface which rule failed.
+func IsValidPassword(password string) bool {
+	return ActivePasswordPolicy.Validate(password) == nil
 }
 
 // IsValidName validates first/last names
@@ -103,8 +159,8 @@ func ValidateRegistrationInput(firstName, lastName, email, password string) erro
 		return errors.New("invalid email format")
 	}
 
-	if !IsValidPassword(password) {
-		return errors.New("invalid password format")
+	if err := ActivePasswordPolicy.Validate(password); err != nil {
+		return err
 	}
 
 	return nil